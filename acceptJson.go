@@ -2,16 +2,31 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type ChirpParam struct {
-	Body string `json:"body"`
+	Body      string        `json:"body"`
+	ParentID  uuid.NullUUID `json:"parent_id"`
+	QuoteOfID uuid.NullUUID `json:"quote_of_id"`
+	// Set to schedule the chirp instead of publishing it straight away
+	PublishAt *time.Time `json:"publish_at"`
+	Poll      *PollParam `json:"poll"`
+	// public (default), unlisted, followers or private
+	Visibility string `json:"visibility"`
+	// Shown instead of the body until the reader chooses to see it
+	ContentWarning string `json:"content_warning"`
 }
 
-func jsonHandlerForChirp(w http.ResponseWriter, r *http.Request) ChirpParam {
+// Decodes and validates a chirp body. When it returns false the error
+// response has already been written and the caller should just return.
+func jsonHandlerForChirp(w http.ResponseWriter, r *http.Request, maxLength int) (ChirpParam, bool) {
 
 	decoder := json.NewDecoder(r.Body)
 	data := ChirpParam{}
@@ -22,19 +37,64 @@ func jsonHandlerForChirp(w http.ResponseWriter, r *http.Request) ChirpParam {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(400)
 		w.Write([]byte(`{"error": "Something went wrong"}`))
-		return ChirpParam{}
+		return ChirpParam{}, false
 	}
 
-	if len(data.Body) > 140 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(400)
-		w.Write([]byte(`{"error": "Chirp is too long"}`))
-		return ChirpParam{}
+	return validateChirp(w, data, maxLength)
+}
+
+// Applies the length limit and bad word filter every new chirp body goes
+// through, however it was sent. maxLength depends on the author's tier, see
+// maxChirpLength. When it returns false the error response has already been
+// written.
+func validateChirp(w http.ResponseWriter, data ChirpParam, maxLength int) (ChirpParam, bool) {
+	data, err := checkChirp(data, maxLength)
+	var lengthErr chirpLengthError
+	if errors.As(err, &lengthErr) {
+		writeChirpLengthError(w, lengthErr)
+		return ChirpParam{}, false
+	}
+	if err != nil {
+		writeChirpError(w, err.Error())
+		return ChirpParam{}, false
+	}
+
+	return data, true
+}
+
+// Does the work of validateChirp for callers that report errors their own
+// way. A body over the limit gives a chirpLengthError.
+func checkChirp(data ChirpParam, maxLength int) (ChirpParam, error) {
+	if length := chirpLength(data.Body); length > maxLength {
+		return ChirpParam{}, chirpLengthError{Length: length, MaxLength: maxLength}
+	}
+
+	if data.PublishAt != nil {
+		if err := validatePublishAt(*data.PublishAt); err != nil {
+			return ChirpParam{}, err
+		}
+	}
+
+	visibility, err := validateVisibility(data.Visibility)
+	if err != nil {
+		return ChirpParam{}, err
+	}
+	data.Visibility = visibility
+
+	data.ContentWarning, err = validateContentWarning(data.ContentWarning)
+	if err != nil {
+		return ChirpParam{}, err
+	}
+
+	if data.Poll != nil {
+		if err := validatePoll(data.Poll, data.PublishAt); err != nil {
+			return ChirpParam{}, err
+		}
 	}
 
 	data.Body = CheckForBadWords(data.Body)
 
-	return data
+	return data, nil
 }
 
 func CheckForBadWords(body string) string {
@@ -1,38 +1,121 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
-	"sort"
 	"sync/atomic"
 	"time"
 
 	"github.com/dis012/ChirpyWebServer/internal"
 	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/dis012/ChirpyWebServer/internal/storage"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type apiConfig struct {
 	fileServerHits atomic.Int32
+	db             *sql.DB
 	dbQueries      *database.Queries
 	platform       string
 	secret         string
 	apiKey         string
+	reactions      []string
+	media          storage.Storage
+	restoreWindow  time.Duration
+	chirpLengths   chirpLengthLimits
+	impressions    *impressionRecorder
 }
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`         // Change to lowercase 'id'
-	CreatedAt time.Time `json:"created_at"` // Change to 'created_at'
-	UpdatedAt time.Time `json:"updated_at"` // Change to 'updated_at'
-	UserID    uuid.UUID `json:"user_id"`    // Change to 'user_id'
-	Body      string    `json:"body"`       // Change to 'body'
+	ID         uuid.UUID       `json:"id"`         // Change to lowercase 'id'
+	CreatedAt  time.Time       `json:"created_at"` // Change to 'created_at'
+	UpdatedAt  time.Time       `json:"updated_at"` // Change to 'updated_at'
+	UserID     uuid.UUID       `json:"user_id"`    // Change to 'user_id'
+	Body       string          `json:"body"`       // Change to 'body'
+	ParentID   uuid.NullUUID   `json:"parent_id"`
+	RootID     uuid.NullUUID   `json:"root_id"`
+	ReplyCount int64           `json:"reply_count"`
+	Reactions  []ReactionCount `json:"reactions"`
+	Entities   []ChirpEntity   `json:"entities"`
+	Media      []ChirpMedia    `json:"media"`
+	Bookmarked bool            `json:"bookmarked"`
+	Pinned     bool            `json:"pinned"`
+	Poll       *Poll           `json:"poll"`
+
+	RechirpOfID uuid.NullUUID `json:"rechirp_of_id"`
+	QuoteOfID   uuid.NullUUID `json:"quote_of_id"`
+	// The rechirped or quoted chirp, embedded so feeds can render it
+	Original *Chirp `json:"original,omitempty"`
+
+	Published bool       `json:"published"`
+	PublishAt *time.Time `json:"publish_at"`
+
+	Visibility string `json:"visibility"`
+
+	ContentWarning *string `json:"content_warning"`
+	// Whether clients should hide the body behind the content warning, which
+	// the viewer can turn off in their preferences
+	Collapsed bool `json:"collapsed"`
+}
+
+func newChirp(chirp database.Chirp) Chirp {
+	return Chirp{
+		ID:        chirp.ID,
+		CreatedAt: chirp.CreatedAt,
+		UpdatedAt: chirp.UpdatedAt,
+		UserID:    chirp.UserID,
+		Body:      chirp.Body,
+		ParentID:  chirp.ParentID,
+		RootID:    chirp.RootID,
+		Reactions: []ReactionCount{},
+		Entities:  []ChirpEntity{},
+		Media:     []ChirpMedia{},
+
+		RechirpOfID: chirp.RechirpOfID,
+		QuoteOfID:   chirp.QuoteOfID,
+
+		Published: chirp.Published,
+		PublishAt: nullableTime(chirp.PublishAt),
+
+		Visibility: chirp.Visibility,
+
+		ContentWarning: nullableString(chirp.ContentWarning),
+		Collapsed:      chirp.ContentWarning.Valid,
+	}
 }
 
 type User struct {
 	Email    string `json:"email"`    // Change to 'email'
 	Password string `json:"password"` // Change to 'hashed_password'
+	Handle   string `json:"handle"`
+}
+
+// JSON friendly form of a nullable column, nil when it is NULL
+func nullableString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// JSON friendly form of a nullable timestamp, nil when it is NULL
+func nullableTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// Reports whether err is Postgres rejecting a duplicate value
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
 }
 
 type WebhookData struct {
@@ -82,6 +165,12 @@ func (a *apiConfig) resetMetricsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	err = a.dbQueries.DeleteAllTags(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	err = a.dbQueries.DeleteAllUsers(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -100,6 +189,16 @@ func (a *apiConfig) createNewUserHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	handle := sql.NullString{}
+	if user.Handle != "" {
+		normalized, err := internal.NormalizeHandle(user.Handle)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handle = sql.NullString{String: normalized, Valid: true}
+	}
+
 	hashed_password, err := internal.HashPassword(user.Password)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -109,7 +208,12 @@ func (a *apiConfig) createNewUserHandler(w http.ResponseWriter, r *http.Request)
 	newUser, err := a.dbQueries.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          user.Email,
 		HashedPassword: hashed_password,
+		Handle:         handle,
 	})
+	if isUniqueViolation(err) {
+		http.Error(w, "Email or handle already taken", http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -120,11 +224,17 @@ func (a *apiConfig) createNewUserHandler(w http.ResponseWriter, r *http.Request)
 
 	// Use the json package to encode the response properly
 	response := map[string]interface{}{
-		"id":            newUser.ID,
-		"created_at":    newUser.CreatedAt,
-		"updated_at":    newUser.UpdatedAt,
-		"email":         newUser.Email,
-		"is_chirpy_red": newUser.IsChirpyRed, // This will be a boolean
+		"id":              newUser.ID,
+		"created_at":      newUser.CreatedAt,
+		"updated_at":      newUser.UpdatedAt,
+		"email":           newUser.Email,
+		"is_chirpy_red":   newUser.IsChirpyRed, // This will be a boolean
+		"is_moderator":    newUser.IsModerator,
+		"handle":          nullableString(newUser.Handle),
+		"follower_count":  0,
+		"following_count": 0,
+
+		"expand_content_warnings": newUser.ExpandContentWarnings,
 	}
 
 	json.NewEncoder(w).Encode(response)
@@ -140,16 +250,70 @@ func (a *apiConfig) createNewChirpHandler(w http.ResponseWriter, r *http.Request
 	userID, err := internal.ValidateJWT(tokenString, a.secret)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
 	}
 
-	chirpParam := jsonHandlerForChirp(w, r)
+	maxLength, err := a.maxChirpLength(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	databaseChirpParam := database.CreateChirpParams{
-		Body:   chirpParam.Body,
-		UserID: userID,
+	var chirpParam ChirpParam
+	var uploads []imageUpload
+	ok := false
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		chirpParam, uploads, ok = multipartHandlerForChirp(w, r, maxLength)
+		if r.MultipartForm != nil {
+			defer r.MultipartForm.RemoveAll()
+		}
+	} else {
+		chirpParam, ok = jsonHandlerForChirp(w, r, maxLength)
+	}
+	if !ok {
+		return
+	}
+
+	databaseChirpParam, ok := a.newChirpParams(w, r, userID, chirpParam)
+	if !ok {
+		return
+	}
+
+	images, err := a.storeImages(r.Context(), uploads)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+	// Don't leave files behind for a chirp that was never saved
+	committed := false
+	defer func() {
+		if !committed {
+			a.deleteStoredImages(context.WithoutCancel(r.Context()), images)
+		}
+	}()
 
-	chirp, err := a.dbQueries.CreateChirp(r.Context(), databaseChirpParam)
+	tx, err := a.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := a.dbQueries.WithTx(tx)
+
+	chirp, err := saveNewChirp(r.Context(), qtx, databaseChirpParam, images, chirpParam.Poll)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	committed = true
+
+	response, err := a.chirpsResponse(r.Context(), []database.Chirp{chirp}, uuid.NullUUID{UUID: userID, Valid: true})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -157,95 +321,215 @@ func (a *apiConfig) createNewChirpHandler(w http.ResponseWriter, r *http.Request
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	w.Write([]byte(fmt.Sprintf(`{"id": "%s", "created_at": "%s", "updated_at": "%s", "user_id": "%s", "body": "%s"}`, chirp.ID, chirp.CreatedAt, chirp.UpdatedAt, chirp.UserID, chirp.Body)))
+	json.NewEncoder(w).Encode(response[0])
 }
 
-func (a *apiConfig) getAllChirpsHandler(w http.ResponseWriter, r *http.Request) {
-	queryParam := r.URL.Query().Get("author_id")
+// Works out the row for a new chirp from fields that passed validateChirp:
+// schedules it, and looks up the chirp it replies to or quotes. When it
+// returns false the error response has already been written.
+func (a *apiConfig) newChirpParams(w http.ResponseWriter, r *http.Request, userID uuid.UUID, chirpParam ChirpParam) (database.CreateChirpParams, bool) {
+	databaseChirpParam := database.CreateChirpParams{
+		Body:       chirpParam.Body,
+		UserID:     userID,
+		Published:  true,
+		Visibility: chirpParam.Visibility,
+		ContentWarning: sql.NullString{
+			String: chirpParam.ContentWarning,
+			Valid:  chirpParam.ContentWarning != "",
+		},
+	}
+
+	if chirpParam.PublishAt != nil {
+		databaseChirpParam.PublishAt = sql.NullTime{Time: chirpParam.PublishAt.UTC(), Valid: true}
+		databaseChirpParam.Published = false
+	}
+
+	if chirpParam.ParentID.Valid {
+		parent, err := a.dbQueries.GetChirpById(r.Context(), chirpParam.ParentID.UUID)
+		// A rechirp only shares another chirp, so replying to it replies to
+		// the original, like quoting it does
+		if err == nil && parent.RechirpOfID.Valid {
+			parent, err = a.dbQueries.GetChirpById(r.Context(), parent.RechirpOfID.UUID)
+		}
+		if err == nil {
+			// Only chirps the author can see can be replied to, so not a
+			// scheduled chirp before it goes out
+			err = a.checkCanView(r.Context(), parent, uuid.NullUUID{UUID: userID, Valid: true})
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Parent chirp not found", http.StatusBadRequest)
+			return database.CreateChirpParams{}, false
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return database.CreateChirpParams{}, false
+		}
 
-	queryParamOrderBy := r.URL.Query().Get("sort")
-	if queryParamOrderBy == "" {
-		queryParamOrderBy = "asc"
+		// Every reply points at the chirp that started the conversation
+		databaseChirpParam.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		databaseChirpParam.RootID = parent.RootID
+		if !parent.RootID.Valid {
+			databaseChirpParam.RootID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		}
 	}
 
-	if queryParam != "" {
-		userId, _ := uuid.Parse(queryParam)
-		authorChirps, err := a.dbQueries.GetAuthorChirps(r.Context(), userId)
+	if chirpParam.QuoteOfID.Valid {
+		original, err := a.originalChirp(r.Context(), chirpParam.QuoteOfID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Quoted chirp not found", http.StatusBadRequest)
+			return database.CreateChirpParams{}, false
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return database.CreateChirpParams{}, false
 		}
 
-		if len(authorChirps) == 0 {
-			w.WriteHeader(http.StatusNotFound)
-			return
-		}
+		databaseChirpParam.QuoteOfID = uuid.NullUUID{UUID: original.ID, Valid: true}
+	}
+
+	return databaseChirpParam, true
+}
+
+// Inserts a chirp along with its hashtags, mentions, images and poll. Runs
+// inside the caller's transaction.
+func saveNewChirp(ctx context.Context, q *database.Queries, params database.CreateChirpParams, images []storedImage, poll *PollParam) (database.Chirp, error) {
+	chirp, err := q.CreateChirp(ctx, params)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	err = saveHashtags(ctx, q, chirp)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	err = saveMentions(ctx, q, chirp)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	err = saveChirpMedia(ctx, q, chirp.ID, images)
+	if err != nil {
+		return database.Chirp{}, err
+	}
 
-		if queryParamOrderBy == "desc" {
-			// Sort in descending order
-			sort.Slice(authorChirps, func(i, j int) bool {
-				return authorChirps[i].CreatedAt.After(authorChirps[j].CreatedAt)
-			})
-		} else {
-			// Sort in ascending order (default)
-			sort.Slice(authorChirps, func(i, j int) bool {
-				return authorChirps[i].CreatedAt.Before(authorChirps[j].CreatedAt)
-			})
+	if poll != nil {
+		err = savePoll(ctx, q, chirp.ID, poll)
+		if err != nil {
+			return database.Chirp{}, err
 		}
+	}
+
+	return chirp, nil
+}
+
+func (a *apiConfig) getAllChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	viewer, err := a.optionalUserID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filters, err := parseChirpFilters(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-		var chirpsSet []Chirp
-		for _, chirp := range authorChirps {
-			chirpsSet = append(chirpsSet, Chirp{
-				ID:        chirp.ID,
-				CreatedAt: chirp.CreatedAt,
-				UpdatedAt: chirp.UpdatedAt,
-				UserID:    chirp.UserID,
-				Body:      chirp.Body,
-			})
+	listParams := database.ListChirpsAscParams{
+		AuthorIds: filters.AuthorIDs,
+		ViewerID:  viewer,
+		Since:     filters.Since,
+		Until:     filters.Until,
+		HasMedia:  filters.HasMedia,
+		IsReply:   filters.IsReply,
+		Contains:  filters.Contains,
+	}
+
+	// A profile starts with the author's pinned chirps. They are left out of
+	// the rest of the listing so they don't show up twice.
+	var pinned []database.Chirp
+	if filters.isProfile() {
+		pinned, err = a.dbQueries.GetPinnedChirps(r.Context(), filters.AuthorIDs[0])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for _, chirp := range pinned {
+			listParams.ExcludeIds = append(listParams.ExcludeIds, chirp.ID)
+		}
+		pinned, err = a.visibleChirps(r.Context(), pinned, viewer)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		// Write the array of chirps to the response
-		json.NewEncoder(w).Encode(chirpsSet)
 	}
 
-	chirps, err := a.dbQueries.GetAllChirps(r.Context())
+	chirps, next, prev, head, err := a.listChirpPage(r.Context(), page, listParams, len(pinned))
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if head {
+		chirps = append(pinned, chirps...)
+	}
 
-	if len(chirps) == 0 {
-		w.WriteHeader(http.StatusNoContent)
+	a.recordImpressions(r, chirps, viewer)
+
+	chirpsSet, err := a.chirpsResponse(r.Context(), chirps, viewer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if queryParamOrderBy == "desc" {
-		// Sort in descending order
-		sort.Slice(chirps, func(i, j int) bool {
-			return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
-		})
+	writeCacheableJSON(w, r, ChirpPage{
+		Chirps:     chirpsSet,
+		NextCursor: next,
+		PrevCursor: prev,
+	}, viewer)
+}
+
+// Reads one page of GET /api/chirps. The head of the listing, the page with
+// nothing before it, leaves room for headCount chirps shown above the regular
+// ones, though it always has at least one regular chirp. Paging back to the
+// head serves it the same way as the first page, so it reports head either
+// way.
+func (a *apiConfig) listChirpPage(ctx context.Context, page pageRequest, params database.ListChirpsAscParams, headCount int) ([]database.Chirp, *string, *string, bool, error) {
+	head := page.Cursor == nil
+	if head {
+		page.Limit = max(page.Limit-int32(headCount), 1)
+	}
+
+	params.CursorCreatedAt, params.CursorID = page.cursorArgs()
+	// One extra row tells us whether there is another page
+	params.PageSize = page.Limit + 1
+
+	var chirps []database.Chirp
+	var err error
+	if page.ascending() {
+		chirps, err = a.dbQueries.ListChirpsAsc(ctx, params)
 	} else {
-		// Sort in ascending order (default)
-		sort.Slice(chirps, func(i, j int) bool {
-			return chirps[i].CreatedAt.Before(chirps[j].CreatedAt)
-		})
+		chirps, err = a.dbQueries.ListChirpsDesc(ctx, database.ListChirpsDescParams(params))
 	}
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
+	chirps, next, prev := buildPage(page, chirps, chirpPosition)
 
-	var chirpsSet []Chirp
-	for _, chirp := range chirps {
-		chirpsSet = append(chirpsSet, Chirp{
-			ID:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			UserID:    chirp.UserID,
-			Body:      chirp.Body,
-		})
+	// Paging back ran into the start of the listing
+	if headCount > 0 && page.Cursor != nil && page.Cursor.Before && prev == nil {
+		page.Cursor = nil
+		return a.listChirpPage(ctx, page, params, headCount)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	// Write the array of chirps to the response
-	json.NewEncoder(w).Encode(chirpsSet)
+	return chirps, next, prev, head, nil
 }
 
 func (a *apiConfig) getChirpByIdHandler(w http.ResponseWriter, r *http.Request) {
@@ -255,21 +539,38 @@ func (a *apiConfig) getChirpByIdHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	viewer, err := a.optionalUserID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
 	chirp, err := a.dbQueries.GetChirpById(r.Context(), ChirpParam)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
+	err = a.checkCanView(r.Context(), chirp, viewer)
 	// if chirp is not found, return 404
-	if chirp.ID == uuid.Nil {
+	if chirp.ID == uuid.Nil || errors.Is(err, sql.ErrNoRows) {
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(fmt.Sprintf(`{"id": "%s", "created_at": "%s", "updated_at": "%s", "user_id": "%s", "body": "%s"}`, chirp.ID, chirp.CreatedAt, chirp.UpdatedAt, chirp.UserID, chirp.Body)))
+	a.recordImpressions(r, []database.Chirp{chirp}, viewer)
+
+	response, err := a.chirpsResponse(r.Context(), []database.Chirp{chirp}, viewer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeCacheableJSON(w, r, response[0], viewer)
 }
 
 func (a *apiConfig) loginUser(w http.ResponseWriter, r *http.Request) {
@@ -321,18 +622,30 @@ func (a *apiConfig) loginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	counts, err := a.dbQueries.GetFollowCounts(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
 	// Use the json package to encode the response properly
 	response := map[string]interface{}{
-		"id":            user.ID,
-		"created_at":    user.CreatedAt,
-		"updated_at":    user.UpdatedAt,
-		"email":         user.Email,
-		"is_chirpy_red": user.IsChirpyRed, // This will be a boolean
-		"refresh_token": refreshToken.Token,
-		"token":         token,
+		"id":              user.ID,
+		"created_at":      user.CreatedAt,
+		"updated_at":      user.UpdatedAt,
+		"email":           user.Email,
+		"is_chirpy_red":   user.IsChirpyRed, // This will be a boolean
+		"is_moderator":    user.IsModerator,
+		"handle":          nullableString(user.Handle),
+		"follower_count":  counts.Followers,
+		"following_count": counts.Following,
+		"refresh_token":   refreshToken.Token,
+		"token":           token,
+
+		"expand_content_warnings": user.ExpandContentWarnings,
 	}
 
 	json.NewEncoder(w).Encode(response)
@@ -417,6 +730,17 @@ func (a *apiConfig) updateUserPassAndEmail(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// The handle is left as it is unless a new one is sent
+	handle := sql.NullString{}
+	if newData.Handle != "" {
+		normalized, err := internal.NormalizeHandle(newData.Handle)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handle = sql.NullString{String: normalized, Valid: true}
+	}
+
 	newHashedPassword, err := internal.HashPassword(newData.Password)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -426,8 +750,19 @@ func (a *apiConfig) updateUserPassAndEmail(w http.ResponseWriter, r *http.Reques
 	user, err := a.dbQueries.UpdatePasswordAndEmail(r.Context(), database.UpdatePasswordAndEmailParams{
 		Email:          newData.Email,
 		HashedPassword: newHashedPassword,
+		Handle:         handle,
 		ID:             userId,
 	})
+	if isUniqueViolation(err) {
+		http.Error(w, "Email or handle already taken", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	counts, err := a.dbQueries.GetFollowCounts(r.Context(), user.ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -436,11 +771,17 @@ func (a *apiConfig) updateUserPassAndEmail(w http.ResponseWriter, r *http.Reques
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	response := map[string]interface{}{
-		"id":            user.ID,
-		"created_at":    user.CreatedAt,
-		"updated_at":    user.UpdatedAt,
-		"email":         user.Email,
-		"is_chirpy_red": user.IsChirpyRed, // This will be a boolean
+		"id":              user.ID,
+		"created_at":      user.CreatedAt,
+		"updated_at":      user.UpdatedAt,
+		"email":           user.Email,
+		"is_chirpy_red":   user.IsChirpyRed, // This will be a boolean
+		"is_moderator":    user.IsModerator,
+		"handle":          nullableString(user.Handle),
+		"follower_count":  counts.Followers,
+		"following_count": counts.Following,
+
+		"expand_content_warnings": user.ExpandContentWarnings,
 	}
 
 	json.NewEncoder(w).Encode(response)
@@ -481,6 +822,7 @@ func (a *apiConfig) deleteChirpById(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Images are kept until the chirp is purged, in case it is restored
 	err = a.dbQueries.DeleteChirpById(r.Context(), chirp.ID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusNotFound)
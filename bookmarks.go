@@ -0,0 +1,160 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+func (a *apiConfig) addBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, userID, ok := a.bookmarkRequest(w, r)
+	if !ok {
+		return
+	}
+
+	chirp, err := a.dbQueries.GetChirpById(r.Context(), chirpID)
+	if err == nil {
+		err = a.checkCanView(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Bookmarking twice keeps the original bookmark and its place in the list
+	err = a.dbQueries.CreateBookmark(r.Context(), database.CreateBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiConfig) deleteBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, userID, ok := a.bookmarkRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := a.dbQueries.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Lists the caller's bookmarked chirps, most recently bookmarked first.
+// Paginated like GET /api/chirps, but the order is fixed.
+func (a *apiConfig) getBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page.Desc = true
+
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	listParams := database.ListBookmarksDescParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        page.Limit + 1,
+	}
+
+	var rows []database.ListBookmarksDescRow
+	if page.ascending() {
+		var ascRows []database.ListBookmarksAscRow
+		ascRows, err = a.dbQueries.ListBookmarksAsc(r.Context(), database.ListBookmarksAscParams(listParams))
+		for _, row := range ascRows {
+			rows = append(rows, database.ListBookmarksDescRow(row))
+		}
+	} else {
+		rows, err = a.dbQueries.ListBookmarksDesc(r.Context(), listParams)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Bookmarks are paged by when they were made, not when the chirp was
+	rows, next, prev := buildPage(page, rows, func(row database.ListBookmarksDescRow) pageCursor {
+		return pageCursor{CreatedAt: row.BookmarkedAt, ID: row.Chirp.ID}
+	})
+	var chirps []database.Chirp
+	for _, row := range rows {
+		chirps = append(chirps, row.Chirp)
+	}
+
+	viewer := uuid.NullUUID{UUID: userID, Valid: true}
+	chirpsSet, err := a.chirpsResponse(r.Context(), chirps, viewer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ChirpPage{
+		Chirps:     chirpsSet,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
+}
+
+// Reads the chirp ID and the caller from a bookmark request. When it returns
+// false the error response has already been written.
+func (a *apiConfig) bookmarkRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return chirpID, userID, true
+}
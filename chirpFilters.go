@@ -0,0 +1,110 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	maxFilterAuthors        = 50
+	maxFilterContainsLength = 100
+)
+
+// Filters for GET /api/chirps. Unset fields don't filter anything.
+type chirpFilters struct {
+	AuthorIDs []uuid.UUID
+	Since     sql.NullTime
+	Until     sql.NullTime
+	HasMedia  sql.NullBool
+	IsReply   sql.NullBool
+	Contains  sql.NullString
+}
+
+// Reads the listing filters from the query string. author_id can be repeated
+// or hold a comma separated list.
+func parseChirpFilters(query url.Values) (chirpFilters, error) {
+	var filters chirpFilters
+
+	for _, param := range query["author_id"] {
+		for _, value := range strings.Split(param, ",") {
+			authorID, err := uuid.Parse(strings.TrimSpace(value))
+			if err != nil {
+				return chirpFilters{}, fmt.Errorf("Invalid author_id %q", value)
+			}
+			filters.AuthorIDs = append(filters.AuthorIDs, authorID)
+		}
+	}
+	if len(filters.AuthorIDs) > maxFilterAuthors {
+		return chirpFilters{}, fmt.Errorf("At most %d authors can be filtered at once", maxFilterAuthors)
+	}
+
+	var err error
+	filters.Since, err = parseTimeFilter(query, "since")
+	if err != nil {
+		return chirpFilters{}, err
+	}
+	filters.Until, err = parseTimeFilter(query, "until")
+	if err != nil {
+		return chirpFilters{}, err
+	}
+	if filters.Since.Valid && filters.Until.Valid && !filters.Since.Time.Before(filters.Until.Time) {
+		return chirpFilters{}, errors.New("since must be before until")
+	}
+
+	if value := query.Get("has_media"); value != "" {
+		hasMedia, err := strconv.ParseBool(value)
+		if err != nil {
+			return chirpFilters{}, errors.New("has_media must be true or false")
+		}
+		filters.HasMedia = sql.NullBool{Bool: hasMedia, Valid: true}
+	}
+
+	switch query.Get("replies") {
+	case "":
+	case "only":
+		filters.IsReply = sql.NullBool{Bool: true, Valid: true}
+	case "exclude":
+		filters.IsReply = sql.NullBool{Bool: false, Valid: true}
+	default:
+		return chirpFilters{}, errors.New("replies must be only or exclude")
+	}
+
+	if query.Has("contains") {
+		contains := strings.TrimSpace(query.Get("contains"))
+		if contains == "" || utf8.RuneCountInString(contains) > maxFilterContainsLength {
+			return chirpFilters{}, fmt.Errorf("contains must be 1 to %d characters", maxFilterContainsLength)
+		}
+		filters.Contains = sql.NullString{String: contains, Valid: true}
+	}
+
+	return filters, nil
+}
+
+// Reads an RFC 3339 time from the query string, invalid when it isn't there
+func parseTimeFilter(query url.Values, name string) (sql.NullTime, error) {
+	value := query.Get(name)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+// Reports whether the listing is someone's profile as is, which starts with
+// their pinned chirps
+func (f chirpFilters) isProfile() bool {
+	return len(f.AuthorIDs) == 1 && !f.Since.Valid && !f.Until.Valid &&
+		!f.HasMedia.Valid && !f.IsReply.Valid && !f.Contains.Valid
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+	"github.com/rivo/uniseg"
+)
+
+const (
+	defaultMaxChirpLength    = 140
+	defaultMaxChirpLengthRed = 280
+	// Every link counts as this many characters however long it is, so
+	// tracking parameters don't eat into the limit
+	urlLength = 23
+)
+
+var urlPattern = regexp.MustCompile(`https?://\S+`)
+
+// How long chirps may be on each subscription tier
+type chirpLengthLimits struct {
+	Standard int
+	Red      int
+}
+
+// Looks up how long the user's chirps may be
+func (a *apiConfig) maxChirpLength(ctx context.Context, userID uuid.UUID) (int, error) {
+	user, err := a.dbQueries.GetUserById(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if user.IsChirpyRed {
+		return a.chirpLengths.Red, nil
+	}
+	return a.chirpLengths.Standard, nil
+}
+
+// Length of a chirp body as people see it: characters are grapheme clusters,
+// so an emoji or an accented letter counts once however many bytes or code
+// points it takes, and URLs count as urlLength.
+func chirpLength(body string) int {
+	length := 0
+	start := 0
+	for _, loc := range urlPattern.FindAllStringIndex(body, -1) {
+		length += uniseg.GraphemeClusterCount(body[start:loc[0]]) + urlLength
+		start = loc[1]
+	}
+	return length + uniseg.GraphemeClusterCount(body[start:])
+}
+
+type chirpLengthError struct {
+	Length    int `json:"length"`
+	MaxLength int `json:"max_length"`
+}
+
+func (e chirpLengthError) Error() string {
+	return fmt.Sprintf("Chirp is too long (%d of at most %d characters)", e.Length, e.MaxLength)
+}
+
+func writeChirpLengthError(w http.ResponseWriter, lengthErr chirpLengthError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+		chirpLengthError
+	}{
+		Error:            "Chirp is too long",
+		chirpLengthError: lengthErr,
+	})
+}
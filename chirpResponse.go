@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// Turns database rows into the chirps we send to clients, with rechirped and
+// quoted chirps embedded. viewer is the caller, if they are logged in.
+func (a *apiConfig) chirpsResponse(ctx context.Context, chirps []database.Chirp, viewer uuid.NullUUID) ([]Chirp, error) {
+	response, err := a.decorateChirps(ctx, chirps, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	var originalIDs []uuid.UUID
+	for _, chirp := range chirps {
+		if chirp.RechirpOfID.Valid {
+			originalIDs = append(originalIDs, chirp.RechirpOfID.UUID)
+		}
+		if chirp.QuoteOfID.Valid {
+			originalIDs = append(originalIDs, chirp.QuoteOfID.UUID)
+		}
+	}
+	if len(originalIDs) == 0 {
+		return response, nil
+	}
+
+	originalRows, err := a.dbQueries.GetChirpsByIds(ctx, originalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Originals are only embedded one level deep, and only if the viewer may
+	// see them
+	originalRows, err = a.visibleChirps(ctx, originalRows, viewer)
+	if err != nil {
+		return nil, err
+	}
+	originals, err := a.decorateChirps(ctx, originalRows, viewer)
+	if err != nil {
+		return nil, err
+	}
+	originalsByID := make(map[uuid.UUID]*Chirp, len(originals))
+	for i := range originals {
+		originalsByID[originals[i].ID] = &originals[i]
+	}
+
+	for i := range response {
+		if response[i].RechirpOfID.Valid {
+			response[i].Original = originalsByID[response[i].RechirpOfID.UUID]
+		}
+		if response[i].QuoteOfID.Valid {
+			response[i].Original = originalsByID[response[i].QuoteOfID.UUID]
+		}
+	}
+
+	return response, nil
+}
+
+// Counts and other per-chirp extras are loaded with one query for the whole
+// set rather than one query per chirp.
+func (a *apiConfig) decorateChirps(ctx context.Context, chirps []database.Chirp, viewer uuid.NullUUID) ([]Chirp, error) {
+	response := []Chirp{}
+	if len(chirps) == 0 {
+		return response, nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	replyCounts, err := a.dbQueries.CountRepliesForChirps(ctx, database.CountRepliesForChirpsParams{
+		ChirpIds: chirpIDs,
+		ViewerID: viewer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	repliesByChirp := make(map[uuid.UUID]int64, len(replyCounts))
+	for _, count := range replyCounts {
+		repliesByChirp[count.ParentID.UUID] = count.ReplyCount
+	}
+
+	reactionCounts, err := a.dbQueries.CountReactionsForChirps(ctx, database.CountReactionsForChirpsParams{
+		ViewerID: viewer,
+		ChirpIds: chirpIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	reactionsByChirp := make(map[uuid.UUID][]ReactionCount)
+	for _, count := range reactionCounts {
+		reactionsByChirp[count.ChirpID] = append(reactionsByChirp[count.ChirpID], ReactionCount{
+			Emoji:   count.Emoji,
+			Count:   count.ReactionCount,
+			Reacted: count.Reacted,
+		})
+	}
+
+	mentions, err := a.dbQueries.GetMentionsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	entitiesByChirp := make(map[uuid.UUID][]ChirpEntity)
+	for _, mention := range mentions {
+		entitiesByChirp[mention.ChirpID] = append(entitiesByChirp[mention.ChirpID], ChirpEntity{
+			Type:   "mention",
+			Start:  mention.StartIndex,
+			End:    mention.EndIndex,
+			Handle: mention.Handle,
+			UserID: mention.UserID,
+		})
+	}
+
+	media, err := a.dbQueries.GetMediaForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	mediaByChirp := make(map[uuid.UUID][]ChirpMedia)
+	for _, medium := range media {
+		mediaByChirp[medium.ChirpID] = append(mediaByChirp[medium.ChirpID], ChirpMedia{
+			URL:         a.media.URL(medium.StorageKey),
+			ContentType: medium.ContentType,
+			AltText:     medium.AltText,
+		})
+	}
+
+	pinnedIDs, err := a.dbQueries.GetPinnedChirpIds(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	pinned := make(map[uuid.UUID]bool, len(pinnedIDs))
+	for _, id := range pinnedIDs {
+		pinned[id] = true
+	}
+
+	// Bookmarks are private, so only the caller's own are looked up
+	bookmarked := make(map[uuid.UUID]bool)
+	if viewer.Valid {
+		bookmarkedIDs, err := a.dbQueries.GetBookmarkedChirpIds(ctx, database.GetBookmarkedChirpIdsParams{
+			UserID:   viewer.UUID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range bookmarkedIDs {
+			bookmarked[id] = true
+		}
+	}
+
+	polls, err := a.pollsForChirps(ctx, chirpIDs, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Content warnings start collapsed unless the viewer asked otherwise
+	expandWarnings := false
+	if viewer.Valid {
+		user, err := a.dbQueries.GetUserById(ctx, viewer.UUID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		expandWarnings = user.ExpandContentWarnings
+	}
+
+	for _, chirp := range chirps {
+		c := newChirp(chirp)
+		c.Poll = polls[chirp.ID]
+		c.Collapsed = c.Collapsed && !expandWarnings
+		c.Bookmarked = bookmarked[chirp.ID]
+		c.Pinned = pinned[chirp.ID]
+		c.ReplyCount = repliesByChirp[chirp.ID]
+		if reactions, ok := reactionsByChirp[chirp.ID]; ok {
+			c.Reactions = reactions
+		}
+		if entities, ok := entitiesByChirp[chirp.ID]; ok {
+			c.Entities = entities
+		}
+		if media, ok := mediaByChirp[chirp.ID]; ok {
+			c.Media = media
+		}
+		response = append(response, c)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// Previous version of an edited chirp
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// Body of an edit. Only the text of a chirp can be changed after it is
+// posted.
+type ChirpEditParam struct {
+	Body string `json:"body"`
+}
+
+// Decodes and validates an edit. Fields a new chirp takes, like visibility
+// or poll, are rejected rather than ignored. When it returns false the error
+// response has already been written.
+func decodeChirpEdit(w http.ResponseWriter, r *http.Request, maxLength int) (ChirpEditParam, bool) {
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	var param ChirpEditParam
+	err := decoder.Decode(&param)
+	if err != nil {
+		writeChirpError(w, err.Error())
+		return ChirpEditParam{}, false
+	}
+
+	chirpParam, ok := validateChirp(w, ChirpParam{Body: param.Body}, maxLength)
+	if !ok {
+		return ChirpEditParam{}, false
+	}
+
+	return ChirpEditParam{Body: chirpParam.Body}, true
+}
+
+func (a *apiConfig) updateChirpHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	maxLength, err := a.maxChirpLength(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	editParam, ok := decodeChirpEdit(w, r, maxLength)
+	if !ok {
+		return
+	}
+
+	tx, err := a.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := a.dbQueries.WithTx(tx)
+
+	// Lock the row so concurrent edits can't lose a revision
+	chirp, err := qtx.GetChirpByIdForUpdate(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if chirp.UserID != userID {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if chirp.RechirpOfID.Valid {
+		http.Error(w, "Rechirps can't be edited", http.StatusBadRequest)
+		return
+	}
+
+	if chirp.Body != editParam.Body {
+		_, err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+			ChirpID:   chirp.ID,
+			Body:      chirp.Body,
+			CreatedAt: chirp.BodyUpdatedAt,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		chirp, err = qtx.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+			Body: editParam.Body,
+			ID:   chirp.ID,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// The edit may have added or removed hashtags and mentions
+		err = qtx.DeleteChirpTags(r.Context(), chirp.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = saveHashtags(r.Context(), qtx, chirp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = qtx.DeleteChirpMentions(r.Context(), chirp.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		err = saveMentions(r.Context(), qtx, chirp)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := a.chirpsResponse(r.Context(), []database.Chirp{chirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response[0])
+}
+
+func (a *apiConfig) getChirpHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	viewer, err := a.optionalUserID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	chirp, err := a.dbQueries.GetChirpById(r.Context(), chirpID)
+	if err == nil {
+		err = a.checkCanView(r.Context(), chirp, viewer)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	revisions, err := a.dbQueries.GetChirpRevisions(r.Context(), chirp.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Oldest version first, the current body is the chirp itself
+	history := []ChirpRevision{}
+	for _, revision := range revisions {
+		history = append(history, ChirpRevision{
+			ID:         revision.ID,
+			ChirpID:    revision.ChirpID,
+			Body:       revision.Body,
+			CreatedAt:  revision.CreatedAt,
+			ReplacedAt: revision.ReplacedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(history)
+}
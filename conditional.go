@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How long shared caches may serve an anonymous chirp response before asking
+// again. Authenticated responses are always revalidated.
+const publicChirpMaxAge = 10 * time.Second
+
+// Writes v as JSON with an ETag so clients and caches can revalidate instead
+// of fetching it again. The ETag is a hash of the body, so it also changes
+// when counts like reactions or replies change. None of those touch
+// updated_at, which is why there is no Last-Modified. Responses for a signed
+// in viewer are private to them.
+func writeCacheableJSON(w http.ResponseWriter, r *http.Request, v interface{}, viewer uuid.NullUUID) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	sum := sha256.Sum256(body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Add("Vary", "Authorization")
+	if viewer.Valid {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(publicChirpMaxAge.Seconds())))
+	}
+
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body.Bytes())
+}
+
+// Reports whether the client's copy, named by If-None-Match, is still
+// current
+func notModified(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+	"github.com/rivo/uniseg"
+)
+
+const maxContentWarningLength = 100
+
+type ContentWarningParam struct {
+	ContentWarning string `json:"content_warning"`
+}
+
+type PreferencesParam struct {
+	ExpandContentWarnings bool `json:"expand_content_warnings"`
+}
+
+// Tidies up a content warning. An empty one means the chirp has none.
+func validateContentWarning(warning string) (string, error) {
+	warning = strings.TrimSpace(warning)
+	if uniseg.GraphemeClusterCount(warning) > maxContentWarningLength {
+		return "", fmt.Errorf("Content warnings can be at most %d characters", maxContentWarningLength)
+	}
+	return CheckForBadWords(warning), nil
+}
+
+// Puts a content warning on a chirp, or replaces the one it has. Authors can
+// do this for their own chirps and moderators for anyone's. A warning put
+// there by a moderator can only be changed by a moderator.
+func (a *apiConfig) setContentWarningHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, user, ok := a.contentWarningRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var param ContentWarningParam
+	err := json.NewDecoder(r.Body).Decode(&param)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	warning, err := validateContentWarning(param.ContentWarning)
+	if err == nil && warning == "" {
+		err = errors.New("content_warning is required, use DELETE to remove it")
+	}
+	if err != nil {
+		writeChirpError(w, err.Error())
+		return
+	}
+
+	a.saveContentWarning(w, r, chirpID, user, sql.NullString{String: warning, Valid: true})
+}
+
+func (a *apiConfig) deleteContentWarningHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, user, ok := a.contentWarningRequest(w, r)
+	if !ok {
+		return
+	}
+
+	a.saveContentWarning(w, r, chirpID, user, sql.NullString{})
+}
+
+// Stores the warning if user may change the chirp's warning and responds with
+// the updated chirp
+func (a *apiConfig) saveContentWarning(w http.ResponseWriter, r *http.Request, chirpID uuid.UUID, user database.User, warning sql.NullString) {
+	viewer := uuid.NullUUID{UUID: user.ID, Valid: true}
+
+	chirp, err := a.dbQueries.GetChirpById(r.Context(), chirpID)
+	if err == nil && !user.IsModerator {
+		err = a.checkCanView(r.Context(), chirp, viewer)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if chirp.UserID != user.ID && !user.IsModerator {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if chirp.ContentWarningByModerator && !user.IsModerator {
+		http.Error(w, "A moderator set this content warning", http.StatusForbidden)
+		return
+	}
+
+	chirp, err = a.dbQueries.SetContentWarning(r.Context(), database.SetContentWarningParams{
+		ContentWarning:            warning,
+		ContentWarningByModerator: warning.Valid && user.IsModerator,
+		ID:                        chirpID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := a.chirpsResponse(r.Context(), []database.Chirp{chirp}, viewer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response[0])
+}
+
+// Reads the chirp ID and looks up the caller for a content warning request.
+// When it returns false the error response has already been written.
+func (a *apiConfig) contentWarningRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, database.User, bool) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return uuid.Nil, database.User{}, false
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, database.User{}, false
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, database.User{}, false
+	}
+
+	user, err := a.dbQueries.GetUserById(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return uuid.Nil, database.User{}, false
+	}
+
+	return chirpID, user, true
+}
+
+func (a *apiConfig) updatePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var param PreferencesParam
+	err = json.NewDecoder(r.Body).Decode(&param)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := a.dbQueries.UpdateUserPreferences(r.Context(), database.UpdateUserPreferencesParams{
+		ExpandContentWarnings: param.ExpandContentWarnings,
+		ID:                    userID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	counts, err := a.dbQueries.GetFollowCounts(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := map[string]interface{}{
+		"id":                      user.ID,
+		"created_at":              user.CreatedAt,
+		"updated_at":              user.UpdatedAt,
+		"email":                   user.Email,
+		"is_chirpy_red":           user.IsChirpyRed,
+		"is_moderator":            user.IsModerator,
+		"handle":                  nullableString(user.Handle),
+		"follower_count":          counts.Followers,
+		"following_count":         counts.Following,
+		"expand_content_warnings": user.ExpandContentWarnings,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultRestoreWindow = 30 * 24 * time.Hour
+	defaultPurgeInterval = time.Hour
+)
+
+// Deleted chirps older than this can no longer be restored and are purged
+func (a *apiConfig) restoreCutoff() time.Time {
+	return time.Now().UTC().Add(-a.restoreWindow)
+}
+
+func (a *apiConfig) restoreChirpHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	chirp, err := a.dbQueries.GetDeletedChirpById(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if chirp.UserID != userID {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if chirp.DeletedAt.Time.Before(a.restoreCutoff()) {
+		http.Error(w, "Chirp was deleted too long ago to restore", http.StatusGone)
+		return
+	}
+
+	// A rechirp only makes sense while the chirp it points at is still around
+	if chirp.RechirpOfID.Valid {
+		_, err := a.dbQueries.GetChirpById(r.Context(), chirp.RechirpOfID.UUID)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Rechirped chirp was deleted", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	rows, err := a.dbQueries.RestoreChirp(r.Context(), database.RestoreChirpParams{
+		ID:        chirp.ID,
+		DeletedAt: chirp.DeletedAt,
+	})
+	if isUniqueViolation(err) {
+		http.Error(w, "Chirp has been rechirped again since it was deleted", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Purged or restored by another request in the meantime
+	if rows == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	chirp, err = a.dbQueries.GetChirpById(r.Context(), chirp.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := a.chirpsResponse(r.Context(), []database.Chirp{chirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response[0])
+}
+
+// Permanently removes chirps deleted longer ago than the restore window,
+// along with their images. Run periodically from main.
+func (a *apiConfig) purgeDeletedChirps(ctx context.Context) error {
+	cutoff := sql.NullTime{Time: a.restoreCutoff(), Valid: true}
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := a.dbQueries.WithTx(tx)
+
+	storageKeys, err := qtx.LockPurgeableChirpMedia(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	purged, err := qtx.PurgeDeletedChirps(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Files go only once the rows pointing at them are gone for good
+	for _, key := range storageKeys {
+		if err := a.media.Delete(ctx, key); err != nil {
+			log.Printf("Error deleting media %s: %v", key, err)
+		}
+	}
+
+	if purged > 0 {
+		log.Printf("Purged %d deleted chirps", purged)
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// Drafts only go through the chirp checks when they are published, so they
+// can be longer than a chirp while being worked on, up to this limit
+const maxDraftLength = 5000
+
+// Unpublished chirp only its author can see
+type Draft struct {
+	ID         uuid.UUID     `json:"id"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	UserID     uuid.UUID     `json:"user_id"`
+	Body       string        `json:"body"`
+	ParentID   uuid.NullUUID `json:"parent_id"`
+	QuoteOfID  uuid.NullUUID `json:"quote_of_id"`
+	Visibility string        `json:"visibility"`
+}
+
+type DraftParam struct {
+	Body       string        `json:"body"`
+	ParentID   uuid.NullUUID `json:"parent_id"`
+	QuoteOfID  uuid.NullUUID `json:"quote_of_id"`
+	Visibility string        `json:"visibility"`
+}
+
+func newDraft(draft database.Draft) Draft {
+	return Draft{
+		ID:         draft.ID,
+		CreatedAt:  draft.CreatedAt,
+		UpdatedAt:  draft.UpdatedAt,
+		UserID:     draft.UserID,
+		Body:       draft.Body,
+		ParentID:   draft.ParentID,
+		QuoteOfID:  draft.QuoteOfID,
+		Visibility: draft.Visibility,
+	}
+}
+
+// Decodes a draft from the request. When it returns false the error
+// response has already been written.
+func decodeDraftParam(w http.ResponseWriter, r *http.Request) (DraftParam, bool) {
+	var param DraftParam
+	err := json.NewDecoder(r.Body).Decode(&param)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return DraftParam{}, false
+	}
+
+	if utf8.RuneCountInString(param.Body) > maxDraftLength {
+		writeChirpError(w, fmt.Sprintf("Drafts can be at most %d characters", maxDraftLength))
+		return DraftParam{}, false
+	}
+
+	visibility, err := validateVisibility(param.Visibility)
+	if err != nil {
+		writeChirpError(w, err.Error())
+		return DraftParam{}, false
+	}
+	param.Visibility = visibility
+
+	return param, true
+}
+
+func (a *apiConfig) createDraftHandler(w http.ResponseWriter, r *http.Request) {
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	param, ok := decodeDraftParam(w, r)
+	if !ok {
+		return
+	}
+
+	draft, err := a.dbQueries.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:     userID,
+		Body:       param.Body,
+		ParentID:   param.ParentID,
+		QuoteOfID:  param.QuoteOfID,
+		Visibility: param.Visibility,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newDraft(draft))
+}
+
+func (a *apiConfig) getDraftsHandler(w http.ResponseWriter, r *http.Request) {
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	drafts, err := a.dbQueries.GetDraftsForUser(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Most recently edited first
+	response := []Draft{}
+	for _, draft := range drafts {
+		response = append(response, newDraft(draft))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *apiConfig) getDraftHandler(w http.ResponseWriter, r *http.Request) {
+	draftID, userID, ok := a.draftRequest(w, r)
+	if !ok {
+		return
+	}
+
+	draft, err := a.dbQueries.GetDraftById(r.Context(), database.GetDraftByIdParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newDraft(draft))
+}
+
+func (a *apiConfig) updateDraftHandler(w http.ResponseWriter, r *http.Request) {
+	draftID, userID, ok := a.draftRequest(w, r)
+	if !ok {
+		return
+	}
+
+	param, ok := decodeDraftParam(w, r)
+	if !ok {
+		return
+	}
+
+	draft, err := a.dbQueries.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		Body:       param.Body,
+		ParentID:   param.ParentID,
+		QuoteOfID:  param.QuoteOfID,
+		Visibility: param.Visibility,
+		ID:         draftID,
+		UserID:     userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newDraft(draft))
+}
+
+func (a *apiConfig) deleteDraftHandler(w http.ResponseWriter, r *http.Request) {
+	draftID, userID, ok := a.draftRequest(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := a.dbQueries.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Turns a draft into a chirp. The draft goes through the same checks as a
+// chirp sent to POST /api/chirps and is removed in the same transaction that
+// creates the chirp, so it is never both published and still a draft.
+func (a *apiConfig) publishDraftHandler(w http.ResponseWriter, r *http.Request) {
+	draftID, userID, ok := a.draftRequest(w, r)
+	if !ok {
+		return
+	}
+
+	maxLength, err := a.maxChirpLength(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tx, err := a.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := a.dbQueries.WithTx(tx)
+
+	// Deleting first also locks the draft against a concurrent publish. If
+	// the draft turns out not to be publishable the rollback brings it back.
+	draft, err := qtx.DeleteDraft(r.Context(), database.DeleteDraftParams{
+		ID:     draftID,
+		UserID: userID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	chirpParam, ok := validateChirp(w, ChirpParam{
+		Body:       draft.Body,
+		ParentID:   draft.ParentID,
+		QuoteOfID:  draft.QuoteOfID,
+		Visibility: draft.Visibility,
+	}, maxLength)
+	if !ok {
+		return
+	}
+
+	databaseChirpParam, ok := a.newChirpParams(w, r, userID, chirpParam)
+	if !ok {
+		return
+	}
+
+	chirp, err := saveNewChirp(r.Context(), qtx, databaseChirpParam, nil, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := a.chirpsResponse(r.Context(), []database.Chirp{chirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response[0])
+}
+
+// Reads the draft ID and the caller from a request to /api/drafts/{id}.
+// Drafts are private, so someone else's draft simply isn't found. When it
+// returns false the error response has already been written.
+func (a *apiConfig) draftRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	draftID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return draftID, userID, true
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// Chirps are read and written this many at a time, so an export never holds
+// more than one batch in memory
+const exportBatchSize = 500
+
+// One chirp in an export. The same shape is accepted by the import.
+type ChirpExport struct {
+	ID             uuid.UUID     `json:"id"`
+	CreatedAt      time.Time     `json:"created_at"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Body           string        `json:"body"`
+	ParentID       uuid.NullUUID `json:"parent_id"`
+	RechirpOfID    uuid.NullUUID `json:"rechirp_of_id"`
+	QuoteOfID      uuid.NullUUID `json:"quote_of_id"`
+	Visibility     string        `json:"visibility"`
+	Published      bool          `json:"published"`
+	PublishAt      *time.Time    `json:"publish_at"`
+	ContentWarning *string       `json:"content_warning"`
+}
+
+var exportCSVHeader = []string{
+	"id", "created_at", "updated_at", "body", "parent_id", "rechirp_of_id",
+	"quote_of_id", "visibility", "published", "publish_at", "content_warning",
+}
+
+func newChirpExport(chirp database.Chirp) ChirpExport {
+	return ChirpExport{
+		ID:             chirp.ID,
+		CreatedAt:      chirp.CreatedAt,
+		UpdatedAt:      chirp.UpdatedAt,
+		Body:           chirp.Body,
+		ParentID:       chirp.ParentID,
+		RechirpOfID:    chirp.RechirpOfID,
+		QuoteOfID:      chirp.QuoteOfID,
+		Visibility:     chirp.Visibility,
+		Published:      chirp.Published,
+		PublishAt:      nullableTime(chirp.PublishAt),
+		ContentWarning: nullableString(chirp.ContentWarning),
+	}
+}
+
+func (c ChirpExport) csvRecord() []string {
+	nullUUID := func(id uuid.NullUUID) string {
+		if !id.Valid {
+			return ""
+		}
+		return id.UUID.String()
+	}
+
+	publishAt := ""
+	if c.PublishAt != nil {
+		publishAt = c.PublishAt.Format(time.RFC3339)
+	}
+
+	contentWarning := ""
+	if c.ContentWarning != nil {
+		contentWarning = *c.ContentWarning
+	}
+
+	return []string{
+		c.ID.String(),
+		c.CreatedAt.Format(time.RFC3339),
+		c.UpdatedAt.Format(time.RFC3339),
+		c.Body,
+		nullUUID(c.ParentID),
+		nullUUID(c.RechirpOfID),
+		nullUUID(c.QuoteOfID),
+		c.Visibility,
+		strconv.FormatBool(c.Published),
+		publishAt,
+		contentWarning,
+	}
+}
+
+// Writes one chirp at a time in the format the client asked for
+type chirpExportWriter interface {
+	Write(ChirpExport) error
+	Flush() error
+}
+
+type jsonLinesExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (e jsonLinesExportWriter) Write(c ChirpExport) error {
+	// Encode ends every value with a newline
+	return e.encoder.Encode(c)
+}
+
+func (e jsonLinesExportWriter) Flush() error {
+	return nil
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func newCSVExportWriter(w io.Writer) csvExportWriter {
+	writer := csv.NewWriter(w)
+	// Errors stick to the writer and come out of the first Flush
+	writer.Write(exportCSVHeader)
+	return csvExportWriter{writer: writer}
+}
+
+func (e csvExportWriter) Write(c ChirpExport) error {
+	return e.writer.Write(c.csvRecord())
+}
+
+func (e csvExportWriter) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// Works out the export format from the format parameter, falling back to the
+// Accept header and then JSON Lines
+func exportFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "jsonl", "csv":
+		return format, nil
+	case "":
+	default:
+		return "", errors.New("format must be jsonl or csv")
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(accept))
+		switch mediaType {
+		case "text/csv":
+			return "csv", nil
+		case "application/jsonl", "application/x-ndjson":
+			return "jsonl", nil
+		}
+	}
+
+	return "jsonl", nil
+}
+
+// Streams all of the caller's chirps, oldest first, as JSON Lines or CSV
+func (a *apiConfig) exportChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	format, err := exportFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Read the first batch before committing to a response, so a failing
+	// database still gets a proper error
+	batch, err := a.dbQueries.ExportAuthorChirps(r.Context(), database.ExportAuthorChirpsParams{
+		UserID:   userID,
+		PageSize: exportBatchSize,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var exporter chirpExportWriter
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="chirps.csv"`)
+		exporter = newCSVExportWriter(w)
+	} else {
+		w.Header().Set("Content-Type", "application/jsonl; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="chirps.jsonl"`)
+		exporter = jsonLinesExportWriter{encoder: json.NewEncoder(w)}
+	}
+	w.WriteHeader(http.StatusOK)
+
+	// The status is already out, so all we can do about a failure from here on
+	// is log it and cut the response short
+	err = a.streamChirpExport(r.Context(), w, exporter, userID, batch)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("Error exporting chirps of %s: %v", userID, err)
+	}
+}
+
+// Writes batch and every batch after it, flushing each one to the client
+func (a *apiConfig) streamChirpExport(ctx context.Context, w http.ResponseWriter, exporter chirpExportWriter, userID uuid.UUID, batch []database.Chirp) error {
+	rc := http.NewResponseController(w)
+
+	for {
+		for _, chirp := range batch {
+			if err := exporter.Write(newChirpExport(chirp)); err != nil {
+				return err
+			}
+		}
+		if err := exporter.Flush(); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+
+		last := batch[len(batch)-1]
+		var err error
+		batch, err = a.dbQueries.ExportAuthorChirps(ctx, database.ExportAuthorChirpsParams{
+			UserID:          userID,
+			CursorCreatedAt: sql.NullTime{Time: last.CreatedAt, Valid: true},
+			CursorID:        uuid.NullUUID{UUID: last.ID, Valid: true},
+			PageSize:        exportBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// A user in a followers or following list
+type FollowUser struct {
+	ID             uuid.UUID `json:"id"`
+	Handle         *string   `json:"handle"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+	FollowedAt     time.Time `json:"followed_at"`
+}
+
+// Page of users returned by the followers and following endpoints
+type UserPage struct {
+	Users      []FollowUser `json:"users"`
+	NextCursor *string      `json:"next_cursor"`
+	PrevCursor *string      `json:"prev_cursor"`
+}
+
+func (a *apiConfig) followUserHandler(w http.ResponseWriter, r *http.Request) {
+	followedID, userID, ok := a.followRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if followedID == userID {
+		http.Error(w, "You can't follow yourself", http.StatusBadRequest)
+		return
+	}
+
+	_, err := a.dbQueries.GetUserById(r.Context(), followedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := a.dbQueries.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: userID,
+		FollowedID: followedID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		http.Error(w, "You already follow this user", http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiConfig) unfollowUserHandler(w http.ResponseWriter, r *http.Request) {
+	followedID, userID, ok := a.followRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := a.dbQueries.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: userID,
+		FollowedID: followedID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Shows another user's public profile. Private details like the email stay
+// with the user's own responses.
+func (a *apiConfig) getUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	user, err := a.dbQueries.GetUserById(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	counts, err := a.dbQueries.GetFollowCounts(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	response := map[string]interface{}{
+		"id":              user.ID,
+		"created_at":      user.CreatedAt,
+		"handle":          nullableString(user.Handle),
+		"is_chirpy_red":   user.IsChirpyRed,
+		"follower_count":  counts.Followers,
+		"following_count": counts.Following,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// Lists who follows the user, most recent follower first
+func (a *apiConfig) getFollowersHandler(w http.ResponseWriter, r *http.Request) {
+	a.listFollows(w, r, false)
+}
+
+// Lists who the user follows, most recently followed first
+func (a *apiConfig) getFollowingHandler(w http.ResponseWriter, r *http.Request) {
+	a.listFollows(w, r, true)
+}
+
+// Serves a page of the user's followers, or of the users they follow.
+// Paginated like GET /api/chirps, but the order is fixed.
+func (a *apiConfig) listFollows(w http.ResponseWriter, r *http.Request, following bool) {
+	userID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page.Desc = true
+
+	_, err = a.dbQueries.GetUserById(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	listParams := database.ListFollowersDescParams{
+		UserID:          userID,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        page.Limit + 1,
+	}
+
+	// All four queries return the same columns
+	var rows []database.ListFollowersDescRow
+	switch {
+	case following && page.ascending():
+		var followingRows []database.ListFollowingAscRow
+		followingRows, err = a.dbQueries.ListFollowingAsc(r.Context(), database.ListFollowingAscParams(listParams))
+		for _, row := range followingRows {
+			rows = append(rows, database.ListFollowersDescRow(row))
+		}
+	case following:
+		var followingRows []database.ListFollowingDescRow
+		followingRows, err = a.dbQueries.ListFollowingDesc(r.Context(), database.ListFollowingDescParams(listParams))
+		for _, row := range followingRows {
+			rows = append(rows, database.ListFollowersDescRow(row))
+		}
+	case page.ascending():
+		var ascRows []database.ListFollowersAscRow
+		ascRows, err = a.dbQueries.ListFollowersAsc(r.Context(), database.ListFollowersAscParams(listParams))
+		for _, row := range ascRows {
+			rows = append(rows, database.ListFollowersDescRow(row))
+		}
+	default:
+		rows, err = a.dbQueries.ListFollowersDesc(r.Context(), listParams)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Follows are paged by when they were made
+	rows, next, prev := buildPage(page, rows, func(row database.ListFollowersDescRow) pageCursor {
+		return pageCursor{CreatedAt: row.FollowedAt, ID: row.ID}
+	})
+
+	var userIDs []uuid.UUID
+	for _, row := range rows {
+		userIDs = append(userIDs, row.ID)
+	}
+	counts, err := a.dbQueries.GetFollowCountsForUsers(r.Context(), userIDs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	countsByID := make(map[uuid.UUID]database.GetFollowCountsForUsersRow, len(counts))
+	for _, count := range counts {
+		countsByID[count.ID] = count
+	}
+
+	users := []FollowUser{}
+	for _, row := range rows {
+		users = append(users, FollowUser{
+			ID:             row.ID,
+			Handle:         nullableString(row.Handle),
+			FollowerCount:  countsByID[row.ID].Followers,
+			FollowingCount: countsByID[row.ID].Following,
+			FollowedAt:     row.FollowedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(UserPage{
+		Users:      users,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
+}
+
+// Reads the user to follow and the caller from a follow request. When it
+// returns false the error response has already been written.
+func (a *apiConfig) followRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	followedID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return followedID, userID, true
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+)
+
+const (
+	// How long a response is kept for retries
+	idempotencyKeyTTL           = 24 * time.Hour
+	idempotencyKeyPurgeInterval = time.Hour
+	maxIdempotencyKeyLength     = 255
+	// Requests are read whole to be hashed. Chirps with images are the
+	// largest we expect.
+	maxIdempotentRequestSize = 25 << 20
+)
+
+// Captures the response of a request sent with an Idempotency-Key so it can
+// be replayed
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Makes a POST endpoint safe to retry. When a request carries an
+// Idempotency-Key header, the first successful response for that key is
+// stored and sent again for every retry instead of running next again.
+// Reusing a key for a different request is rejected. Responses other than
+// 2xx aren't kept, so a request that failed can be fixed and retried with
+// the same key.
+func (a *apiConfig) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestSize))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		requestHash, err := hashRequest(r, body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		scope := a.idempotencyScope(r)
+
+		claimed, err := a.dbQueries.ClaimIdempotencyKey(r.Context(), database.ClaimIdempotencyKeyParams{
+			Scope:         scope,
+			Key:           key,
+			RequestHash:   requestHash,
+			ExpiredBefore: time.Now().UTC().Add(-idempotencyKeyTTL),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if claimed == 0 {
+			a.replayIdempotentResponse(w, r, scope, key, requestHash)
+			return
+		}
+
+		// The client may be gone by now, but the outcome still has to be kept
+		ctx := context.WithoutCancel(r.Context())
+		release := func() error {
+			return a.dbQueries.ReleaseIdempotencyKey(ctx, database.ReleaseIdempotencyKeyParams{
+				Scope: scope,
+				Key:   key,
+			})
+		}
+
+		// A handler that panics never finishes, so give the key up rather
+		// than leave retries stuck on 409 until it expires
+		defer func() {
+			if p := recover(); p != nil {
+				if err := release(); err != nil {
+					log.Printf("Error releasing Idempotency-Key %q: %v", key, err)
+				}
+				panic(p)
+			}
+		}()
+
+		recorder := &recordingResponseWriter{ResponseWriter: w}
+		next(recorder, r)
+
+		if recorder.status < 200 || recorder.status > 299 {
+			err = release()
+		} else {
+			err = a.dbQueries.SaveIdempotentResponse(ctx, database.SaveIdempotentResponseParams{
+				StatusCode: sql.NullInt32{Int32: int32(recorder.status), Valid: true},
+				ContentType: sql.NullString{
+					String: recorder.Header().Get("Content-Type"),
+					Valid:  recorder.Header().Get("Content-Type") != "",
+				},
+				ResponseBody: recorder.body.Bytes(),
+				Scope:        scope,
+				Key:          key,
+			})
+		}
+		if err != nil {
+			log.Printf("Error saving response for Idempotency-Key %q: %v", key, err)
+		}
+	}
+}
+
+// Answers a retry with the stored response of the first request
+func (a *apiConfig) replayIdempotentResponse(w http.ResponseWriter, r *http.Request, scope, key, requestHash string) {
+	stored, err := a.dbQueries.GetIdempotencyKey(r.Context(), database.GetIdempotencyKeyParams{
+		Scope: scope,
+		Key:   key,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// The first request failed and gave the key up in the meantime
+		http.Error(w, "A request with this Idempotency-Key just failed, retry it", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if stored.RequestHash != requestHash {
+		http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if !stored.StatusCode.Valid {
+		http.Error(w, "A request with this Idempotency-Key is still being handled", http.StatusConflict)
+		return
+	}
+
+	if stored.ContentType.Valid {
+		w.Header().Set("Content-Type", stored.ContentType.String)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(int(stored.StatusCode.Int32))
+	w.Write(stored.ResponseBody)
+}
+
+// Keys are only unique per endpoint and caller, so nobody gets a response
+// replayed without the credentials of the request that made it. Callers are
+// told apart by their access token or the Polka API key. Anyone else, like
+// someone signing up, shares the endpoint's keys. A replay still needs the
+// exact request that was first sent, which carries their password.
+func (a *apiConfig) idempotencyScope(r *http.Request) string {
+	scope := r.Method + " " + r.URL.Path
+	if tokenString, err := internal.GetBearerToken(r.Header); err == nil {
+		if userID, err := internal.ValidateJWT(tokenString, a.secret); err == nil {
+			return scope + " user:" + userID.String()
+		}
+	}
+	if apiKey, err := internal.GetAPIKey(r.Header); err == nil && apiKey == a.apiKey {
+		return scope + " polka"
+	}
+	return scope
+}
+
+// Fingerprints a request so that a retry matches it and anything else
+// doesn't. Multipart bodies are hashed part by part, because clients pick a
+// new random boundary for every attempt.
+func hashRequest(r *http.Request, body []byte) (string, error) {
+	h := sha256.New()
+
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+	io.WriteString(h, mediaType)
+	h.Write([]byte{0})
+
+	if mediaType != "multipart/form-data" {
+		h.Write(body)
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return "", err
+		}
+		for _, field := range []string{part.FormName(), part.FileName(), part.Header.Get("Content-Type")} {
+			io.WriteString(h, field)
+			h.Write([]byte{0})
+		}
+		binary.Write(h, binary.BigEndian, int64(len(content)))
+		h.Write(content)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Forgets responses that are too old to be replayed. Run periodically from
+// main.
+func (a *apiConfig) purgeIdempotencyKeys(ctx context.Context) error {
+	_, err := a.dbQueries.DeleteExpiredIdempotencyKeys(ctx, time.Now().UTC().Add(-idempotencyKeyTTL))
+	return err
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxImportSize      = 10 << 20
+	maxImportLines     = 10000
+	maxImportLineBytes = 64 << 10
+	// Chirps are inserted this many at a time
+	importBatchSize = 500
+)
+
+// One line of an import. It takes the shape of an export. id is only used to
+// find the parent or quoted chirp of later lines in the same import, the
+// imported chirp gets a new one.
+type ImportParam struct {
+	ID          uuid.UUID     `json:"id"`
+	Body        string        `json:"body"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ParentID    uuid.NullUUID `json:"parent_id"`
+	RechirpOfID uuid.NullUUID `json:"rechirp_of_id"`
+	QuoteOfID   uuid.NullUUID `json:"quote_of_id"`
+	Visibility  string        `json:"visibility"`
+	// Missing means published, so files written by hand don't need it
+	Published      *bool      `json:"published"`
+	PublishAt      *time.Time `json:"publish_at"`
+	ContentWarning string     `json:"content_warning"`
+
+	line int
+}
+
+// A checked line with the chirp it becomes
+type importedChirp struct {
+	ImportParam
+	newID    uuid.UUID
+	parentID uuid.NullUUID
+	rootID   uuid.NullUUID
+	quoteOf  uuid.NullUUID
+}
+
+// Rechirps only point at someone else's chirp, so there is nothing of the
+// user's own to bring over. They are skipped rather than failing the import.
+var errImportRechirp = errors.New("Rechirps can't be imported, skipped")
+
+type ImportLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Checks one line of an import the way a new chirp is checked
+func checkImportLine(line []byte, maxLength int) (ImportParam, error) {
+	var param ImportParam
+	if err := json.Unmarshal(line, &param); err != nil {
+		return ImportParam{}, errors.New("Invalid JSON")
+	}
+
+	if param.RechirpOfID.Valid {
+		return ImportParam{}, errImportRechirp
+	}
+
+	if param.CreatedAt.IsZero() {
+		return ImportParam{}, errors.New("created_at is required")
+	}
+	if param.CreatedAt.After(time.Now()) {
+		return ImportParam{}, errors.New("created_at can't be in the future")
+	}
+
+	// Scheduled chirps stay scheduled, the publisher takes them from here
+	if param.Published != nil && !*param.Published && param.PublishAt == nil {
+		return ImportParam{}, errors.New("publish_at is required for unpublished chirps")
+	}
+
+	chirpParam, err := checkChirp(ChirpParam{
+		Body:           param.Body,
+		Visibility:     param.Visibility,
+		ContentWarning: param.ContentWarning,
+	}, maxLength)
+	if err != nil {
+		return ImportParam{}, err
+	}
+
+	param.Body = chirpParam.Body
+	param.Visibility = chirpParam.Visibility
+	param.ContentWarning = chirpParam.ContentWarning
+	param.CreatedAt = param.CreatedAt.UTC()
+	if param.PublishAt != nil {
+		publishAt := param.PublishAt.UTC()
+		param.PublishAt = &publishAt
+	}
+	return param, nil
+}
+
+// Works out the parent, thread and quoted chirp of every chirp. References
+// to a chirp earlier in the import point at its new copy, anything else has
+// to be an existing chirp the user can see. Lines whose references can't be
+// resolved are returned as errors.
+func (a *apiConfig) resolveImportReferences(ctx context.Context, params []ImportParam, userID uuid.UUID) ([]importedChirp, []ImportLineError, error) {
+	inImport := make(map[uuid.UUID]bool)
+	for _, param := range params {
+		if param.ID != uuid.Nil {
+			inImport[param.ID] = true
+		}
+	}
+
+	var existingIDs []uuid.UUID
+	for _, param := range params {
+		for _, ref := range []uuid.NullUUID{param.ParentID, param.QuoteOfID} {
+			if ref.Valid && !inImport[ref.UUID] {
+				existingIDs = append(existingIDs, ref.UUID)
+			}
+		}
+	}
+
+	existing := make(map[uuid.UUID]database.Chirp)
+	if len(existingIDs) > 0 {
+		rows, err := a.dbQueries.GetChirpsByIds(ctx, existingIDs)
+		if err != nil {
+			return nil, nil, err
+		}
+		rows, err = a.visibleChirps(ctx, rows, uuid.NullUUID{UUID: userID, Valid: true})
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, chirp := range rows {
+			existing[chirp.ID] = chirp
+		}
+	}
+
+	// Chirps of this import seen so far, by the ID they had in the file
+	imported := make(map[uuid.UUID]importedChirp)
+	chirps := []importedChirp{}
+	lineErrors := []ImportLineError{}
+	for _, param := range params {
+		chirp := importedChirp{ImportParam: param, newID: uuid.New()}
+
+		if param.ParentID.Valid {
+			if parent, ok := imported[param.ParentID.UUID]; ok {
+				chirp.parentID = uuid.NullUUID{UUID: parent.newID, Valid: true}
+				chirp.rootID = parent.rootID
+			} else if parent, ok := existing[param.ParentID.UUID]; ok {
+				chirp.parentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+				chirp.rootID = parent.RootID
+			} else {
+				lineErrors = append(lineErrors, ImportLineError{Line: param.line, Error: "Parent chirp not found, it has to exist or come earlier in the import"})
+				continue
+			}
+			if !chirp.rootID.Valid {
+				chirp.rootID = chirp.parentID
+			}
+		}
+
+		if param.QuoteOfID.Valid {
+			if quoted, ok := imported[param.QuoteOfID.UUID]; ok {
+				chirp.quoteOf = uuid.NullUUID{UUID: quoted.newID, Valid: true}
+			} else if quoted, ok := existing[param.QuoteOfID.UUID]; ok {
+				chirp.quoteOf = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+			} else {
+				lineErrors = append(lineErrors, ImportLineError{Line: param.line, Error: "Quoted chirp not found, it has to exist or come earlier in the import"})
+				continue
+			}
+		}
+
+		if param.ID != uuid.Nil {
+			imported[param.ID] = chirp
+		}
+		chirps = append(chirps, chirp)
+	}
+
+	return chirps, lineErrors, nil
+}
+
+// Imports chirps from a JSON Lines file, one chirp per line. Either every
+// line is imported or, if any line is invalid, none are and the response
+// lists what is wrong with each bad line. Rechirps are the exception: they
+// are skipped and listed in the response, so an export can be imported as
+// is.
+func (a *apiConfig) importChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	maxLength, err := a.maxChirpLength(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 0, 4096), maxImportLineBytes)
+
+	var params []ImportParam
+	lineErrors := []ImportLineError{}
+	skipped := []ImportLineError{}
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Bytes()
+		if strings.TrimSpace(string(line)) == "" {
+			continue
+		}
+
+		if len(params)+len(lineErrors)+len(skipped) == maxImportLines {
+			http.Error(w, fmt.Sprintf("An import can have at most %d chirps", maxImportLines), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		param, err := checkImportLine(line, maxLength)
+		if errors.Is(err, errImportRechirp) {
+			skipped = append(skipped, ImportLineError{Line: lineNumber, Error: err.Error()})
+			continue
+		}
+		if err != nil {
+			lineErrors = append(lineErrors, ImportLineError{Line: lineNumber, Error: err.Error()})
+			continue
+		}
+		param.line = lineNumber
+		params = append(params, param)
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(scanner.Err(), &maxBytesErr) {
+		http.Error(w, fmt.Sprintf("Imports can be at most %d MB", maxImportSize>>20), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		lineErrors = append(lineErrors, ImportLineError{Line: lineNumber + 1, Error: "Line is too long"})
+	} else if scanner.Err() != nil {
+		http.Error(w, scanner.Err().Error(), http.StatusBadRequest)
+		return
+	}
+
+	chirps, referenceErrors, err := a.resolveImportReferences(r.Context(), params, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	lineErrors = append(lineErrors, referenceErrors...)
+	slices.SortFunc(lineErrors, func(a, b ImportLineError) int {
+		return a.Line - b.Line
+	})
+
+	if len(lineErrors) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(struct {
+			Errors []ImportLineError `json:"errors"`
+		}{
+			Errors: lineErrors,
+		})
+		return
+	}
+
+	tx, err := a.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := a.dbQueries.WithTx(tx)
+
+	for start := 0; start < len(chirps); start += importBatchSize {
+		batch := chirps[start:min(start+importBatchSize, len(chirps))]
+
+		batchParams := database.ImportChirpsParams{UserID: userID}
+		for _, chirp := range batch {
+			publishAt := sql.NullTime{}
+			if chirp.PublishAt != nil {
+				publishAt = sql.NullTime{Time: *chirp.PublishAt, Valid: true}
+			}
+			batchParams.Ids = append(batchParams.Ids, chirp.newID)
+			batchParams.CreatedAts = append(batchParams.CreatedAts, chirp.CreatedAt)
+			batchParams.Bodies = append(batchParams.Bodies, chirp.Body)
+			batchParams.ParentIds = append(batchParams.ParentIds, chirp.parentID)
+			batchParams.RootIds = append(batchParams.RootIds, chirp.rootID)
+			batchParams.QuoteOfIds = append(batchParams.QuoteOfIds, chirp.quoteOf)
+			batchParams.PublishAts = append(batchParams.PublishAts, publishAt)
+			batchParams.Published = append(batchParams.Published, chirp.Published == nil || *chirp.Published)
+			batchParams.Visibilities = append(batchParams.Visibilities, chirp.Visibility)
+			batchParams.ContentWarnings = append(batchParams.ContentWarnings, chirp.ContentWarning)
+		}
+
+		imported, err := qtx.ImportChirps(r.Context(), batchParams)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		for _, chirp := range imported {
+			if err := saveHashtags(r.Context(), qtx, chirp); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if err := saveMentions(r.Context(), qtx, chirp); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		Imported int               `json:"imported"`
+		Skipped  []ImportLineError `json:"skipped"`
+	}{
+		Imported: len(chirps),
+		Skipped:  skipped,
+	})
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	// A viewer counts once per chirp within each bucket
+	impressionBucket               = time.Hour
+	defaultImpressionFlushInterval = 10 * time.Second
+	impressionBatchSize            = 1000
+	// Past this many unwritten impressions new ones are dropped, so a
+	// database that can't keep up doesn't take all our memory with it
+	maxPendingImpressions = 100000
+
+	defaultStatsDays = 30
+	maxStatsDays     = 90
+	maxTopChirps     = 10
+)
+
+type impression struct {
+	chirpID   uuid.UUID
+	viewerKey string
+	bucket    time.Time
+}
+
+// Collects impressions in memory so that serving chirps never waits on
+// writing them. flushImpressions moves them to the database in batches.
+// Duplicates within a bucket collapse here already.
+type impressionRecorder struct {
+	mu      sync.Mutex
+	pending map[impression]struct{}
+	dropped int
+}
+
+func newImpressionRecorder() *impressionRecorder {
+	return &impressionRecorder{pending: make(map[impression]struct{})}
+}
+
+func (ir *impressionRecorder) add(imp impression) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	if len(ir.pending) >= maxPendingImpressions {
+		ir.dropped++
+		return
+	}
+	ir.pending[imp] = struct{}{}
+}
+
+// Hands over everything recorded so far and starts afresh
+func (ir *impressionRecorder) take() ([]impression, int) {
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+
+	taken := make([]impression, 0, len(ir.pending))
+	for imp := range ir.pending {
+		taken = append(taken, imp)
+	}
+	dropped := ir.dropped
+	ir.pending = make(map[impression]struct{})
+	ir.dropped = 0
+	return taken, dropped
+}
+
+// Identifies who is looking. Anonymous viewers are told apart by address and
+// browser, hashed so we don't keep either.
+func viewerKey(r *http.Request, viewer uuid.NullUUID) string {
+	if viewer.Valid {
+		return "user:" + viewer.UUID.String()
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	sum := sha256.Sum256([]byte(host + "\x00" + r.UserAgent()))
+	return "anon:" + hex.EncodeToString(sum[:16])
+}
+
+// Counts the chirps as seen by the caller. Authors looking at their own
+// chirps don't count.
+func (a *apiConfig) recordImpressions(r *http.Request, chirps []database.Chirp, viewer uuid.NullUUID) {
+	key := viewerKey(r, viewer)
+	bucket := time.Now().UTC().Truncate(impressionBucket)
+
+	for _, chirp := range chirps {
+		if viewer.Valid && viewer.UUID == chirp.UserID {
+			continue
+		}
+		a.impressions.add(impression{chirpID: chirp.ID, viewerKey: key, bucket: bucket})
+	}
+}
+
+// Writes the impressions recorded since the last run. Run periodically from
+// main. A batch that fails to save is lost, which stats can live with.
+func (a *apiConfig) flushImpressions(ctx context.Context) error {
+	pending, dropped := a.impressions.take()
+	if dropped > 0 {
+		log.Printf("Dropped %d impressions, too many were waiting to be saved", dropped)
+	}
+
+	for start := 0; start < len(pending); start += impressionBatchSize {
+		batch := pending[start:min(start+impressionBatchSize, len(pending))]
+
+		var params database.RecordImpressionsParams
+		for _, imp := range batch {
+			params.ChirpIds = append(params.ChirpIds, imp.chirpID)
+			params.ViewerKeys = append(params.ViewerKeys, imp.viewerKey)
+			params.Buckets = append(params.Buckets, imp.bucket)
+		}
+
+		if err := a.dbQueries.RecordImpressions(ctx, params); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type ChirpStats struct {
+	ChirpID       uuid.UUID `json:"chirp_id"`
+	Impressions   int64     `json:"impressions"`
+	UniqueViewers int64     `json:"unique_viewers"`
+	Reactions     int64     `json:"reactions"`
+	Replies       int64     `json:"replies"`
+	Rechirps      int64     `json:"rechirps"`
+	Quotes        int64     `json:"quotes"`
+	Bookmarks     int64     `json:"bookmarks"`
+	// Engagements per impression, 0 until the chirp has been seen
+	EngagementRate float64 `json:"engagement_rate"`
+}
+
+type TopChirpStats struct {
+	ID            uuid.UUID `json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	Body          string    `json:"body"`
+	Impressions   int64     `json:"impressions"`
+	UniqueViewers int64     `json:"unique_viewers"`
+}
+
+type AuthorStats struct {
+	Since         time.Time       `json:"since"`
+	Impressions   int64           `json:"impressions"`
+	UniqueViewers int64           `json:"unique_viewers"`
+	TopChirps     []TopChirpStats `json:"top_chirps"`
+}
+
+func (a *apiConfig) chirpStatsHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := a.statsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	chirp, err := a.dbQueries.GetChirpById(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if chirp.UserID != userID {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	row, err := a.dbQueries.GetChirpStats(r.Context(), chirpID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stats := ChirpStats{
+		ChirpID:       chirpID,
+		Impressions:   row.Impressions,
+		UniqueViewers: row.UniqueViewers,
+		Reactions:     row.Reactions,
+		Replies:       row.Replies,
+		Rechirps:      row.Rechirps,
+		Quotes:        row.Quotes,
+		Bookmarks:     row.Bookmarks,
+	}
+	if stats.Impressions > 0 {
+		engagements := stats.Reactions + stats.Replies + stats.Rechirps + stats.Quotes + stats.Bookmarks
+		stats.EngagementRate = float64(engagements) / float64(stats.Impressions)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// Sums up how the caller's chirps did over the last days (30 by default)
+func (a *apiConfig) authorStatsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := a.statsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	days := defaultStatsDays
+	if param := r.URL.Query().Get("days"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 1 || n > maxStatsDays {
+			http.Error(w, "days must be between 1 and "+strconv.Itoa(maxStatsDays), http.StatusBadRequest)
+			return
+		}
+		days = n
+	}
+	since := time.Now().UTC().Truncate(impressionBucket).Add(-time.Duration(days) * 24 * time.Hour)
+
+	totals, err := a.dbQueries.GetAuthorImpressionTotals(r.Context(), database.GetAuthorImpressionTotalsParams{
+		UserID: userID,
+		Since:  since,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	top, err := a.dbQueries.GetTopAuthorChirps(r.Context(), database.GetTopAuthorChirpsParams{
+		UserID:    userID,
+		Since:     since,
+		MaxChirps: maxTopChirps,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stats := AuthorStats{
+		Since:         since,
+		Impressions:   totals.Impressions,
+		UniqueViewers: totals.UniqueViewers,
+		TopChirps:     []TopChirpStats{},
+	}
+	for _, chirp := range top {
+		stats.TopChirps = append(stats.TopChirps, TopChirpStats(chirp))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stats)
+}
+
+// Authenticates a stats request. Stats are a Chirpy Red feature. When it
+// returns false the error response has already been written.
+func (a *apiConfig) statsRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, false
+	}
+
+	user, err := a.dbQueries.GetUserById(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return uuid.Nil, false
+	}
+
+	if !user.IsChirpyRed {
+		http.Error(w, "Stats are only available with Chirpy Red", http.StatusForbidden)
+		return uuid.Nil, false
+	}
+
+	return userID, true
+}
@@ -7,29 +7,146 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const countRepliesForChirps = `-- name: CountRepliesForChirps :many
+SELECT parent_id, COUNT(*) AS reply_count FROM chirps
+WHERE parent_id = ANY($1::uuid[]) AND published AND deleted_at IS NULL
+AND (
+    visibility IN ('public', 'unlisted')
+    OR user_id = $2
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = $2
+    ))
+)
+GROUP BY parent_id
+`
+
+type CountRepliesForChirpsParams struct {
+	ChirpIds []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+type CountRepliesForChirpsRow struct {
+	ParentID   uuid.NullUUID
+	ReplyCount int64
+}
+
+// Only counts replies the viewer may see, the same ones the thread shows
+func (q *Queries) CountRepliesForChirps(ctx context.Context, arg CountRepliesForChirpsParams) ([]CountRepliesForChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, countRepliesForChirps, pq.Array(arg.ChirpIds), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountRepliesForChirpsRow
+	for rows.Next() {
+		var i CountRepliesForChirpsRow
+		if err := rows.Scan(&i.ParentID, &i.ReplyCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, user_id, body)
+INSERT INTO chirps (id, created_at, updated_at, user_id, body, parent_id, root_id, quote_of_id, publish_at, published, visibility, content_warning)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, created_at, updated_at, user_id, body
+RETURNING id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at
 `
 
 type CreateChirpParams struct {
-	UserID uuid.UUID
-	Body   string
+	UserID         uuid.UUID
+	Body           string
+	ParentID       uuid.NullUUID
+	RootID         uuid.NullUUID
+	QuoteOfID      uuid.NullUUID
+	PublishAt      sql.NullTime
+	Published      bool
+	Visibility     string
+	ContentWarning sql.NullString
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.UserID, arg.Body)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.UserID,
+		arg.Body,
+		arg.ParentID,
+		arg.RootID,
+		arg.QuoteOfID,
+		arg.PublishAt,
+		arg.Published,
+		arg.Visibility,
+		arg.ContentWarning,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.RootID,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.Published,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.ContentWarningByModerator,
+		&i.BodyUpdatedAt,
+	)
+	return i, err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, user_id, body, rechirp_of_id)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    '',
+    $2
+)
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL AND deleted_at IS NULL DO NOTHING
+RETURNING id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at
+`
+
+type CreateRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.UserID, arg.RechirpOfID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +154,17 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.Body,
+		&i.ParentID,
+		&i.RootID,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.Published,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.ContentWarningByModerator,
+		&i.BodyUpdatedAt,
 	)
 	return i, err
 }
@@ -51,22 +179,75 @@ func (q *Queries) DeleteAllChirps(ctx context.Context) error {
 }
 
 const deleteChirpById = `-- name: DeleteChirpById :exec
-DELETE FROM chirps
-WHERE id = $1
+UPDATE chirps
+SET deleted_at = NOW()
+WHERE (id = $1 OR rechirp_of_id = $1) AND deleted_at IS NULL
 `
 
+// Leaves a tombstone so the chirp can be restored. Rechirps of it go with it,
+// as they did when rows were deleted for real.
 func (q *Queries) DeleteChirpById(ctx context.Context, id uuid.UUID) error {
 	_, err := q.db.ExecContext(ctx, deleteChirpById, id)
 	return err
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, user_id, body FROM chirps
-ORDER BY created_at ASC
+const deleteRechirp = `-- name: DeleteRechirp :execrows
+DELETE FROM chirps
+WHERE user_id = $1 AND rechirp_of_id = $2 AND deleted_at IS NULL
+`
+
+type DeleteRechirpParams struct {
+	UserID      uuid.UUID
+	RechirpOfID uuid.NullUUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.RechirpOfID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteScheduledChirp = `-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND NOT published AND deleted_at IS NULL
+`
+
+func (q *Queries) DeleteScheduledChirp(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteScheduledChirp, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const exportAuthorChirps = `-- name: ExportAuthorChirps :many
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE user_id = $1 AND deleted_at IS NULL
+AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) > ($2::timestamp, $3::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT $4
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+type ExportAuthorChirpsParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+// All of a user's chirps, scheduled ones included, read in batches
+func (q *Queries) ExportAuthorChirps(ctx context.Context, arg ExportAuthorChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, exportAuthorChirps,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -80,6 +261,17 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.Body,
+			&i.ParentID,
+			&i.RootID,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.Published,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.ContentWarningByModerator,
+			&i.BodyUpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -94,14 +286,72 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getAuthorChirps = `-- name: GetAuthorChirps :many
-SELECT id, created_at, updated_at, user_id, body FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC
+const getChirpById = `-- name: GetChirpById :one
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpById, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.RootID,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.Published,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.ContentWarningByModerator,
+		&i.BodyUpdatedAt,
+	)
+	return i, err
+}
+
+const getChirpByIdForUpdate = `-- name: GetChirpByIdForUpdate :one
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpByIdForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByIdForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.RootID,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.Published,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.ContentWarningByModerator,
+		&i.BodyUpdatedAt,
+	)
+	return i, err
+}
+
+const getChirpsByIds = `-- name: GetChirpsByIds :many
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE id = ANY($1::uuid[]) AND deleted_at IS NULL
 `
 
-func (q *Queries) GetAuthorChirps(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAuthorChirps, userID)
+func (q *Queries) GetChirpsByIds(ctx context.Context, ids []uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIds, pq.Array(ids))
 	if err != nil {
 		return nil, err
 	}
@@ -115,6 +365,17 @@ func (q *Queries) GetAuthorChirps(ctx context.Context, userID uuid.UUID) ([]Chir
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.Body,
+			&i.ParentID,
+			&i.RootID,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.Published,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.ContentWarningByModerator,
+			&i.BodyUpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -129,13 +390,946 @@ func (q *Queries) GetAuthorChirps(ctx context.Context, userID uuid.UUID) ([]Chir
 	return items, nil
 }
 
-const getChirpById = `-- name: GetChirpById :one
-SELECT id, created_at, updated_at, user_id, body FROM chirps
-WHERE id = $1
+const getDeletedChirpById = `-- name: GetDeletedChirpById :one
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE id = $1 AND deleted_at IS NOT NULL
 `
 
-func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirpById, id)
+func (q *Queries) GetDeletedChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedChirpById, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.RootID,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.Published,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.ContentWarningByModerator,
+		&i.BodyUpdatedAt,
+	)
+	return i, err
+}
+
+const getThreadChirps = `-- name: GetThreadChirps :many
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE root_id = $1 AND published AND deleted_at IS NULL
+ORDER BY created_at ASC, id ASC
+`
+
+func (q *Queries) GetThreadChirps(ctx context.Context, rootID uuid.NullUUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getThreadChirps, rootID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.ParentID,
+			&i.RootID,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.Published,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.ContentWarningByModerator,
+			&i.BodyUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const importChirps = `-- name: ImportChirps :many
+INSERT INTO chirps (id, created_at, updated_at, body_updated_at, user_id, body, parent_id, root_id, quote_of_id, publish_at, published, visibility, content_warning)
+SELECT
+    imported.id, imported.created_at, imported.created_at, imported.created_at, $1, imported.body,
+    imported.parent_id, imported.root_id, imported.quote_of_id, imported.publish_at, imported.published, imported.visibility,
+    NULLIF(imported.content_warning, '')
+FROM unnest(
+    $2::uuid[],
+    $3::timestamp[],
+    $4::text[],
+    $5::uuid[],
+    $6::uuid[],
+    $7::uuid[],
+    $8::timestamp[],
+    $9::boolean[],
+    $10::text[],
+    $11::text[]
+) AS imported (id, created_at, body, parent_id, root_id, quote_of_id, publish_at, published, visibility, content_warning)
+RETURNING id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at
+`
+
+type ImportChirpsParams struct {
+	UserID          uuid.UUID
+	Ids             []uuid.UUID
+	CreatedAts      []time.Time
+	Bodies          []string
+	ParentIds       []uuid.NullUUID
+	RootIds         []uuid.NullUUID
+	QuoteOfIds      []uuid.NullUUID
+	PublishAts      []sql.NullTime
+	Published       []bool
+	Visibilities    []string
+	ContentWarnings []string
+}
+
+// Inserts a batch of chirps brought over from elsewhere, keeping the times
+// they were originally posted at. IDs are picked by the caller so replies in
+// the same import can point at their parents.
+func (q *Queries) ImportChirps(ctx context.Context, arg ImportChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, importChirps,
+		arg.UserID,
+		pq.Array(arg.Ids),
+		pq.Array(arg.CreatedAts),
+		pq.Array(arg.Bodies),
+		pq.Array(arg.ParentIds),
+		pq.Array(arg.RootIds),
+		pq.Array(arg.QuoteOfIds),
+		pq.Array(arg.PublishAts),
+		pq.Array(arg.Published),
+		pq.Array(arg.Visibilities),
+		pq.Array(arg.ContentWarnings),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.ParentID,
+			&i.RootID,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.Published,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.ContentWarningByModerator,
+			&i.BodyUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE published AND deleted_at IS NULL
+AND (coalesce(cardinality($1::uuid[]), 0) = 0 OR user_id = ANY($1::uuid[]))
+AND (
+    visibility = 'public'
+    OR user_id = $2
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = $2
+    ))
+)
+AND ($3::timestamp IS NULL OR created_at >= $3::timestamp)
+AND ($4::timestamp IS NULL OR created_at < $4::timestamp)
+AND (
+    $5::bool IS NULL
+    OR EXISTS (SELECT 1 FROM chirp_media WHERE chirp_media.chirp_id = chirps.id) = $5::bool
+)
+AND ($6::bool IS NULL OR (parent_id IS NOT NULL) = $6::bool)
+AND ($7::text IS NULL OR strpos(lower(body), lower($7::text)) > 0)
+AND (coalesce(cardinality($8::uuid[]), 0) = 0 OR NOT id = ANY($8::uuid[]))
+AND (
+    $9::timestamp IS NULL
+    OR (created_at, id) > ($9::timestamp, $10::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT $11
+`
+
+type ListChirpsAscParams struct {
+	AuthorIds       []uuid.UUID
+	ViewerID        uuid.NullUUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	HasMedia        sql.NullBool
+	IsReply         sql.NullBool
+	Contains        sql.NullString
+	ExcludeIds      []uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		pq.Array(arg.AuthorIds),
+		arg.ViewerID,
+		arg.Since,
+		arg.Until,
+		arg.HasMedia,
+		arg.IsReply,
+		arg.Contains,
+		pq.Array(arg.ExcludeIds),
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.ParentID,
+			&i.RootID,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.Published,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.ContentWarningByModerator,
+			&i.BodyUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE published AND deleted_at IS NULL
+AND (coalesce(cardinality($1::uuid[]), 0) = 0 OR user_id = ANY($1::uuid[]))
+AND (
+    visibility = 'public'
+    OR user_id = $2
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = $2
+    ))
+)
+AND ($3::timestamp IS NULL OR created_at >= $3::timestamp)
+AND ($4::timestamp IS NULL OR created_at < $4::timestamp)
+AND (
+    $5::bool IS NULL
+    OR EXISTS (SELECT 1 FROM chirp_media WHERE chirp_media.chirp_id = chirps.id) = $5::bool
+)
+AND ($6::bool IS NULL OR (parent_id IS NOT NULL) = $6::bool)
+AND ($7::text IS NULL OR strpos(lower(body), lower($7::text)) > 0)
+AND (coalesce(cardinality($8::uuid[]), 0) = 0 OR NOT id = ANY($8::uuid[]))
+AND (
+    $9::timestamp IS NULL
+    OR (created_at, id) < ($9::timestamp, $10::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT $11
+`
+
+type ListChirpsDescParams struct {
+	AuthorIds       []uuid.UUID
+	ViewerID        uuid.NullUUID
+	Since           sql.NullTime
+	Until           sql.NullTime
+	HasMedia        sql.NullBool
+	IsReply         sql.NullBool
+	Contains        sql.NullString
+	ExcludeIds      []uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		pq.Array(arg.AuthorIds),
+		arg.ViewerID,
+		arg.Since,
+		arg.Until,
+		arg.HasMedia,
+		arg.IsReply,
+		arg.Contains,
+		pq.Array(arg.ExcludeIds),
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.ParentID,
+			&i.RootID,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.Published,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.ContentWarningByModerator,
+			&i.BodyUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledChirps = `-- name: ListScheduledChirps :many
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE user_id = $1 AND NOT published AND deleted_at IS NULL
+ORDER BY publish_at ASC, id ASC
+`
+
+func (q *Queries) ListScheduledChirps(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledChirps, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.ParentID,
+			&i.RootID,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.Published,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.ContentWarningByModerator,
+			&i.BodyUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockPurgeableChirpMedia = `-- name: LockPurgeableChirpMedia :many
+SELECT chirp_media.storage_key FROM chirp_media
+JOIN chirps ON chirps.id = chirp_media.chirp_id
+WHERE chirps.deleted_at < $1
+FOR UPDATE OF chirps
+`
+
+// Locks the chirps about to be purged so they can't be restored while their
+// files are being removed
+func (q *Queries) LockPurgeableChirpMedia(ctx context.Context, deletedBefore sql.NullTime) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, lockPurgeableChirpMedia, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var storage_key string
+		if err := rows.Scan(&storage_key); err != nil {
+			return nil, err
+		}
+		items = append(items, storage_key)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const publishDueChirps = `-- name: PublishDueChirps :many
+UPDATE chirps
+SET
+    published = true,
+    created_at = publish_at,
+    updated_at = NOW(),
+    body_updated_at = NOW()
+WHERE
+    NOT published AND publish_at <= NOW() AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at
+`
+
+func (q *Queries) PublishDueChirps(ctx context.Context) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, publishDueChirps)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.ParentID,
+			&i.RootID,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.Published,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.ContentWarningByModerator,
+			&i.BodyUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeDeletedChirps = `-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < $1
+`
+
+func (q *Queries) PurgeDeletedChirps(ctx context.Context, deletedBefore sql.NullTime) (int64, error) {
+	result, err := q.db.ExecContext(ctx, purgeDeletedChirps, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rescheduleChirp = `-- name: RescheduleChirp :one
+UPDATE chirps
+SET
+    publish_at = $1,
+    updated_at = NOW()
+WHERE
+    id = $2 AND NOT published AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at
+`
+
+type RescheduleChirpParams struct {
+	PublishAt sql.NullTime
+	ID        uuid.UUID
+}
+
+func (q *Queries) RescheduleChirp(ctx context.Context, arg RescheduleChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, rescheduleChirp, arg.PublishAt, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.RootID,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.Published,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.ContentWarningByModerator,
+		&i.BodyUpdatedAt,
+	)
+	return i, err
+}
+
+const restoreChirp = `-- name: RestoreChirp :execrows
+UPDATE chirps
+SET deleted_at = NULL
+WHERE (id = $1 OR rechirp_of_id = $1) AND deleted_at = $2
+`
+
+type RestoreChirpParams struct {
+	ID        uuid.UUID
+	DeletedAt sql.NullTime
+}
+
+// Brings back the chirp and the rechirps that were deleted along with it
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreChirp, arg.ID, arg.DeletedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchChirpsByRecencyAsc = `-- name: SearchChirpsByRecencyAsc :many
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE published AND deleted_at IS NULL
+AND to_tsvector('english', body) @@ websearch_to_tsquery('english', $1)
+AND ($2::uuid IS NULL OR user_id = $2)
+AND (
+    visibility = 'public'
+    OR user_id = $3
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = $3
+    ))
+)
+AND (
+    $4::timestamp IS NULL
+    OR (created_at, id) > ($4::timestamp, $5::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT $6
+`
+
+type SearchChirpsByRecencyAscParams struct {
+	Query           string
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) SearchChirpsByRecencyAsc(ctx context.Context, arg SearchChirpsByRecencyAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirpsByRecencyAsc,
+		arg.Query,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.ParentID,
+			&i.RootID,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.Published,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.ContentWarningByModerator,
+			&i.BodyUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirpsByRecencyDesc = `-- name: SearchChirpsByRecencyDesc :many
+SELECT id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at FROM chirps
+WHERE published AND deleted_at IS NULL
+AND to_tsvector('english', body) @@ websearch_to_tsquery('english', $1)
+AND ($2::uuid IS NULL OR user_id = $2)
+AND (
+    visibility = 'public'
+    OR user_id = $3
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = $3
+    ))
+)
+AND (
+    $4::timestamp IS NULL
+    OR (created_at, id) < ($4::timestamp, $5::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT $6
+`
+
+type SearchChirpsByRecencyDescParams struct {
+	Query           string
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+func (q *Queries) SearchChirpsByRecencyDesc(ctx context.Context, arg SearchChirpsByRecencyDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirpsByRecencyDesc,
+		arg.Query,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.UserID,
+			&i.Body,
+			&i.ParentID,
+			&i.RootID,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.PublishAt,
+			&i.Published,
+			&i.DeletedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.ContentWarningByModerator,
+			&i.BodyUpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirpsByRelevanceAsc = `-- name: SearchChirpsByRelevanceAsc :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body, chirps.parent_id, chirps.root_id, chirps.rechirp_of_id, chirps.quote_of_id, chirps.publish_at, chirps.published, chirps.deleted_at, chirps.visibility, chirps.content_warning, chirps.content_warning_by_moderator, chirps.body_updated_at, ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', $1))::real AS rank
+FROM chirps
+WHERE chirps.published AND chirps.deleted_at IS NULL
+AND to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', $1)
+AND ($2::uuid IS NULL OR chirps.user_id = $2)
+AND (
+    chirps.visibility = 'public'
+    OR chirps.user_id = $3
+    OR (chirps.visibility = 'followers' AND chirps.user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = $3
+    ))
+)
+AND (
+    $4::real IS NULL
+    OR (ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', $1))::real, chirps.created_at, chirps.id)
+        > ($4::real, $5::timestamp, $6::uuid)
+)
+ORDER BY rank ASC, chirps.created_at ASC, chirps.id ASC
+LIMIT $7
+`
+
+type SearchChirpsByRelevanceAscParams struct {
+	Query           string
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	CursorRank      sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+type SearchChirpsByRelevanceAscRow struct {
+	Chirp Chirp
+	Rank  float32
+}
+
+func (q *Queries) SearchChirpsByRelevanceAsc(ctx context.Context, arg SearchChirpsByRelevanceAscParams) ([]SearchChirpsByRelevanceAscRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirpsByRelevanceAsc,
+		arg.Query,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CursorRank,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsByRelevanceAscRow
+	for rows.Next() {
+		var i SearchChirpsByRelevanceAscRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.UserID,
+			&i.Chirp.Body,
+			&i.Chirp.ParentID,
+			&i.Chirp.RootID,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuoteOfID,
+			&i.Chirp.PublishAt,
+			&i.Chirp.Published,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.ContentWarningByModerator,
+			&i.Chirp.BodyUpdatedAt,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchChirpsByRelevanceDesc = `-- name: SearchChirpsByRelevanceDesc :many
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.user_id, chirps.body, chirps.parent_id, chirps.root_id, chirps.rechirp_of_id, chirps.quote_of_id, chirps.publish_at, chirps.published, chirps.deleted_at, chirps.visibility, chirps.content_warning, chirps.content_warning_by_moderator, chirps.body_updated_at, ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', $1))::real AS rank
+FROM chirps
+WHERE chirps.published AND chirps.deleted_at IS NULL
+AND to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', $1)
+AND ($2::uuid IS NULL OR chirps.user_id = $2)
+AND (
+    chirps.visibility = 'public'
+    OR chirps.user_id = $3
+    OR (chirps.visibility = 'followers' AND chirps.user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = $3
+    ))
+)
+AND (
+    $4::real IS NULL
+    OR (ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', $1))::real, chirps.created_at, chirps.id)
+        < ($4::real, $5::timestamp, $6::uuid)
+)
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT $7
+`
+
+type SearchChirpsByRelevanceDescParams struct {
+	Query           string
+	AuthorID        uuid.NullUUID
+	ViewerID        uuid.NullUUID
+	CursorRank      sql.NullFloat64
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	PageSize        int32
+}
+
+type SearchChirpsByRelevanceDescRow struct {
+	Chirp Chirp
+	Rank  float32
+}
+
+func (q *Queries) SearchChirpsByRelevanceDesc(ctx context.Context, arg SearchChirpsByRelevanceDescParams) ([]SearchChirpsByRelevanceDescRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirpsByRelevanceDesc,
+		arg.Query,
+		arg.AuthorID,
+		arg.ViewerID,
+		arg.CursorRank,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.PageSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsByRelevanceDescRow
+	for rows.Next() {
+		var i SearchChirpsByRelevanceDescRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.UserID,
+			&i.Chirp.Body,
+			&i.Chirp.ParentID,
+			&i.Chirp.RootID,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuoteOfID,
+			&i.Chirp.PublishAt,
+			&i.Chirp.Published,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.ContentWarningByModerator,
+			&i.Chirp.BodyUpdatedAt,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setContentWarning = `-- name: SetContentWarning :one
+UPDATE chirps
+SET
+    content_warning = $1,
+    content_warning_by_moderator = $2,
+    updated_at = NOW()
+WHERE
+    id = $3 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at
+`
+
+type SetContentWarningParams struct {
+	ContentWarning            sql.NullString
+	ContentWarningByModerator bool
+	ID                        uuid.UUID
+}
+
+// Moves updated_at so cached copies are refreshed, but not body_updated_at,
+// the warning isn't part of the body's revisions
+func (q *Queries) SetContentWarning(ctx context.Context, arg SetContentWarningParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, setContentWarning, arg.ContentWarning, arg.ContentWarningByModerator, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.RootID,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.Published,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.ContentWarningByModerator,
+		&i.BodyUpdatedAt,
+	)
+	return i, err
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET
+    body = $1,
+    updated_at = NOW(),
+    body_updated_at = NOW()
+WHERE
+    id = $2 AND deleted_at IS NULL
+RETURNING id, created_at, updated_at, user_id, body, parent_id, root_id, rechirp_of_id, quote_of_id, publish_at, published, deleted_at, visibility, content_warning, content_warning_by_moderator, body_updated_at
+`
+
+type UpdateChirpBodyParams struct {
+	Body string
+	ID   uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.ID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -143,6 +1337,17 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.Body,
+		&i.ParentID,
+		&i.RootID,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.PublishAt,
+		&i.Published,
+		&i.DeletedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.ContentWarningByModerator,
+		&i.BodyUpdatedAt,
 	)
 	return i, err
 }
@@ -11,12 +11,128 @@ import (
 	"github.com/google/uuid"
 )
 
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
-	ID        uuid.UUID
+	ID                        uuid.UUID
+	CreatedAt                 time.Time
+	UpdatedAt                 time.Time
+	UserID                    uuid.UUID
+	Body                      string
+	ParentID                  uuid.NullUUID
+	RootID                    uuid.NullUUID
+	RechirpOfID               uuid.NullUUID
+	QuoteOfID                 uuid.NullUUID
+	PublishAt                 sql.NullTime
+	Published                 bool
+	DeletedAt                 sql.NullTime
+	Visibility                string
+	ContentWarning            sql.NullString
+	ContentWarningByModerator bool
+	BodyUpdatedAt             time.Time
+}
+
+type ChirpImpression struct {
+	ChirpID   uuid.UUID
+	ViewerKey string
+	Bucket    time.Time
+}
+
+type ChirpMedium struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	ChirpID     uuid.UUID
+	Position    int32
+	StorageKey  string
+	ContentType string
+	SizeBytes   int64
+	AltText     string
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type ChirpTag struct {
+	ChirpID   uuid.UUID
+	TagID     uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
+}
+
+type Draft struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Body       string
+	ParentID   uuid.NullUUID
+	QuoteOfID  uuid.NullUUID
+	Visibility string
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FollowedID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type IdempotencyKey struct {
+	Scope        string
+	Key          string
+	RequestHash  string
+	CreatedAt    time.Time
+	StatusCode   sql.NullInt32
+	ContentType  sql.NullString
+	ResponseBody []byte
+}
+
+type Mention struct {
+	ChirpID    uuid.UUID
+	UserID     uuid.UUID
+	Handle     string
+	StartIndex int32
+	EndIndex   int32
+}
+
+type PinnedChirp struct {
 	UserID    uuid.UUID
-	Body      string
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+	ClosesAt  time.Time
+}
+
+type PollOption struct {
+	ID       uuid.UUID
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	OptionID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type Reaction struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	Emoji     string
+	CreatedAt time.Time
 }
 
 type RefreshToken struct {
@@ -28,11 +144,20 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
+type Tag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	Name      string
+}
+
 type User struct {
-	ID             uuid.UUID
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
-	Email          string
-	HashedPassword string
-	IsChirpyRed    bool
+	ID                    uuid.UUID
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	Email                 string
+	HashedPassword        string
+	IsChirpyRed           bool
+	Handle                sql.NullString
+	IsModerator           bool
+	ExpandContentWarnings bool
 }
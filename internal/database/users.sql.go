@@ -7,29 +7,33 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, expand_content_warnings
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Handle)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -38,6 +42,9 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.ExpandContentWarnings,
 	)
 	return i, err
 }
@@ -52,7 +59,7 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, expand_content_warnings FROM users
 WHERE email = $1
 `
 
@@ -66,12 +73,15 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.ExpandContentWarnings,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, expand_content_warnings FROM users
 WHERE id = $1
 `
 
@@ -85,29 +95,127 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.ExpandContentWarnings,
 	)
 	return i, err
 }
 
+const getUserByIdForUpdate = `-- name: GetUserByIdForUpdate :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, expand_content_warnings FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetUserByIdForUpdate(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByIdForUpdate, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.ExpandContentWarnings,
+	)
+	return i, err
+}
+
+const getUsersByHandles = `-- name: GetUsersByHandles :many
+SELECT id, handle FROM users
+WHERE handle = ANY($1::text[])
+`
+
+type GetUsersByHandlesRow struct {
+	ID     uuid.UUID
+	Handle sql.NullString
+}
+
+func (q *Queries) GetUsersByHandles(ctx context.Context, handles []string) ([]GetUsersByHandlesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByHandles, pq.Array(handles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersByHandlesRow
+	for rows.Next() {
+		var i GetUsersByHandlesRow
+		if err := rows.Scan(&i.ID, &i.Handle); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updatePasswordAndEmail = `-- name: UpdatePasswordAndEmail :one
 UPDATE users
 SET 
     email = $1,
     hashed_password = $2,
+    handle = COALESCE($3, handle),
     updated_at = NOW()
 WHERE
-    id = $3
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+    id = $4
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, expand_content_warnings
 `
 
 type UpdatePasswordAndEmailParams struct {
 	Email          string
 	HashedPassword string
+	Handle         sql.NullString
 	ID             uuid.UUID
 }
 
 func (q *Queries) UpdatePasswordAndEmail(ctx context.Context, arg UpdatePasswordAndEmailParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updatePasswordAndEmail, arg.Email, arg.HashedPassword, arg.ID)
+	row := q.db.QueryRowContext(ctx, updatePasswordAndEmail,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.ExpandContentWarnings,
+	)
+	return i, err
+}
+
+const updateUserPreferences = `-- name: UpdateUserPreferences :one
+UPDATE users
+SET
+    expand_content_warnings = $1,
+    updated_at = NOW()
+WHERE
+    id = $2
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, expand_content_warnings
+`
+
+type UpdateUserPreferencesParams struct {
+	ExpandContentWarnings bool
+	ID                    uuid.UUID
+}
+
+func (q *Queries) UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserPreferences, arg.ExpandContentWarnings, arg.ID)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -116,6 +224,9 @@ func (q *Queries) UpdatePasswordAndEmail(ctx context.Context, arg UpdatePassword
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.ExpandContentWarnings,
 	)
 	return i, err
 }
@@ -126,7 +237,7 @@ SET
     is_chirpy_red = true
 WHERE
     id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, handle, is_moderator, expand_content_warnings
 `
 
 func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (User, error) {
@@ -139,6 +250,9 @@ func (q *Queries) UpgradeUser(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Handle,
+		&i.IsModerator,
+		&i.ExpandContentWarnings,
 	)
 	return i, err
 }
@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxHashtagLength = 64
+
+// A hashtag starts with # at the beginning of the text or after a character
+// that can't be part of a word, so "a#b" or "&#39;" are not tags
+var hashtagRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#])#([\p{L}\p{N}_]+)`)
+
+// Returns the distinct hashtags in body, lower cased and without the #, in
+// the order they first appear
+func ExtractHashtags(body string) []string {
+	var tags []string
+	seen := map[string]struct{}{}
+
+	for _, match := range hashtagRegexp.FindAllStringSubmatch(body, -1) {
+		tag := NormalizeHashtag(match[1])
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+// Lower cases a tag and strips a leading #. Tags without a letter (#1) or
+// longer than maxHashtagLength are not tags and come back empty.
+func NormalizeHashtag(tag string) string {
+	tag = strings.ToLower(strings.TrimPrefix(tag, "#"))
+	if utf8.RuneCountInString(tag) > maxHashtagLength || strings.IndexFunc(tag, unicode.IsLetter) < 0 {
+		return ""
+	}
+	return tag
+}
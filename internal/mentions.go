@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Handles are stored lower case, so a mention matches whatever case it's
+// written in
+var handleRegexp = regexp.MustCompile(`^[a-z0-9_]{1,30}$`)
+
+// An @handle must not follow a word character, so emails don't count
+var mentionRegexp = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])(@([A-Za-z0-9_]{1,30}))\b`)
+
+// An @handle found in a chirp. Start and End are offsets in characters
+// (Unicode code points), End exclusive, and cover the @ too.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// Lower cases a handle and checks it only uses letters, digits and _
+func NormalizeHandle(handle string) (string, error) {
+	handle = strings.ToLower(handle)
+	if !handleRegexp.MatchString(handle) {
+		return "", errors.New("handle must be 1 to 30 letters, digits or underscores")
+	}
+	return handle, nil
+}
+
+// Returns every @handle in body in the order they appear
+func ExtractMentions(body string) []Mention {
+	var mentions []Mention
+	for _, match := range mentionRegexp.FindAllStringSubmatchIndex(body, -1) {
+		start, end := match[2], match[3]
+		mentions = append(mentions, Mention{
+			Handle: strings.ToLower(body[match[4]:match[5]]),
+			Start:  utf8.RuneCountInString(body[:start]),
+			End:    utf8.RuneCountInString(body[:end]),
+		})
+	}
+	return mentions
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStorage keeps files in a directory on disk that the server exposes
+// under BaseURL
+type LocalStorage struct {
+	Dir     string
+	BaseURL string
+}
+
+func NewLocalStorage(dir, baseURL string) (*LocalStorage, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalStorage{
+		Dir:     dir,
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+	}, nil
+}
+
+// Keys are plain file names so they can't point outside Dir
+func (s *LocalStorage) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.Dir, key), nil
+}
+
+func (s *LocalStorage) Save(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.BaseURL + "/" + key
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage keeps uploaded files and tells clients where to fetch them
+type Storage interface {
+	// Save writes the contents of r under key
+	Save(ctx context.Context, key string, r io.Reader) error
+	// Open reads back the file stored under key. A missing file gives an
+	// error matching fs.ErrNotExist.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Delete removes the file stored under key. Deleting a missing file is
+	// not an error.
+	Delete(ctx context.Context, key string) error
+	// URL is where clients can download the file stored under key
+	URL(key string) string
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Runs job every interval until ctx is cancelled. Errors are logged and the
+// job is simply tried again on the next tick.
+func runEvery(ctx context.Context, interval time.Duration, name string, job func(context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				log.Printf("Error %s: %v", name, err)
+			}
+		}
+	}
+}
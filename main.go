@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"sync/atomic"
+	"time"
 
 	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/dis012/ChirpyWebServer/internal/storage"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
 )
@@ -43,33 +47,110 @@ func main() {
 
 	dbQueries := database.New(db)
 
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "media"
+	}
+
+	mediaBaseURL := os.Getenv("MEDIA_BASE_URL")
+	if mediaBaseURL == "" {
+		mediaBaseURL = "/media"
+	}
+
+	media, err := storage.NewLocalStorage(mediaDir, mediaBaseURL)
+	if err != nil {
+		log.Fatalf("Error opening media storage: %v", err)
+	}
+
 	apiCfg := apiConfig{
 		fileServerHits: atomic.Int32{},
+		db:             db,
 		dbQueries:      dbQueries,
 		platform:       dbPlatform,
 		secret:         secret,
 		apiKey:         apiKey,
+		reactions:      parseReactions(os.Getenv("CHIRP_REACTIONS")),
+		media:          media,
+		restoreWindow:  durationEnv("CHIRP_RESTORE_WINDOW", defaultRestoreWindow),
+		chirpLengths: chirpLengthLimits{
+			Standard: intEnv("CHIRP_MAX_LENGTH", defaultMaxChirpLength),
+			Red:      intEnv("CHIRP_MAX_LENGTH_RED", defaultMaxChirpLengthRed),
+		},
+		impressions: newImpressionRecorder(),
 	}
 
+	// Flips scheduled chirps to published once their time comes
+	go runEvery(context.Background(), durationEnv("CHIRP_PUBLISH_INTERVAL", defaultPublishInterval),
+		"publishing scheduled chirps", apiCfg.publishDueChirps)
+	// Removes deleted chirps for good once they can't be restored anymore
+	go runEvery(context.Background(), durationEnv("CHIRP_PURGE_INTERVAL", defaultPurgeInterval),
+		"purging deleted chirps", apiCfg.purgeDeletedChirps)
+	// Saves the impressions counted by the read endpoints
+	go runEvery(context.Background(), durationEnv("CHIRP_IMPRESSION_FLUSH_INTERVAL", defaultImpressionFlushInterval),
+		"saving impressions", apiCfg.flushImpressions)
+	// Forgets stored responses for Idempotency-Keys once they expire
+	go runEvery(context.Background(), idempotencyKeyPurgeInterval,
+		"purging idempotency keys", apiCfg.purgeIdempotencyKeys)
+
 	serverMux := http.NewServeMux()
 	// Serve static files from the Chirpy/assets directory, stripping the /app prefix
 	fileServer := http.StripPrefix("/app", http.FileServer(http.Dir(".")))
 	// Wrap the file server with a middleware that increments the hit counter
 	serverMux.Handle("/app/", apiCfg.middlewareMetricsInc(fileServer))
+	// Serve uploaded chirp images
+	serverMux.HandleFunc("GET /media/{key}", apiCfg.getMediaHandler)
 	// Register the /healthz endpoint for readiness checks
 	serverMux.HandleFunc("GET /api/healthz", ReadinessHandler)
 	serverMux.HandleFunc("GET /admin/metrics", apiCfg.metricsHandler)
 	serverMux.HandleFunc("/admin/reset", apiCfg.resetMetricsHandler)
-	serverMux.HandleFunc("POST /api/users", apiCfg.createNewUserHandler)
-	serverMux.HandleFunc("POST /api/chirps", apiCfg.createNewChirpHandler)
+	serverMux.HandleFunc("POST /api/users", apiCfg.idempotent(apiCfg.createNewUserHandler))
+	serverMux.HandleFunc("POST /api/chirps", apiCfg.idempotent(apiCfg.createNewChirpHandler))
 	serverMux.HandleFunc("GET /api/chirps", apiCfg.getAllChirpsHandler)
+	serverMux.HandleFunc("GET /api/chirps/search", apiCfg.searchChirpsHandler)
+	serverMux.HandleFunc("GET /api/chirps/scheduled", apiCfg.getScheduledChirpsHandler)
 	serverMux.HandleFunc("GET /api/chirps/{id}", apiCfg.getChirpByIdHandler)
 	serverMux.HandleFunc("POST /api/login", apiCfg.loginUser)
 	serverMux.HandleFunc("POST /api/refresh", apiCfg.refreshToken)
 	serverMux.HandleFunc("POST /api/revoke", apiCfg.revokeToken)
 	serverMux.HandleFunc("PUT /api/users", apiCfg.updateUserPassAndEmail)
+	serverMux.HandleFunc("PUT /api/users/me/preferences", apiCfg.updatePreferencesHandler)
+	serverMux.HandleFunc("GET /api/users/me/stats", apiCfg.authorStatsHandler)
+	serverMux.HandleFunc("GET /api/users/me/chirps/export", apiCfg.exportChirpsHandler)
+	serverMux.HandleFunc("POST /api/users/me/chirps/import", apiCfg.importChirpsHandler)
+	serverMux.HandleFunc("GET /api/users/{id}", apiCfg.getUserHandler)
+	serverMux.HandleFunc("POST /api/users/{id}/follow", apiCfg.followUserHandler)
+	serverMux.HandleFunc("DELETE /api/users/{id}/follow", apiCfg.unfollowUserHandler)
+	serverMux.HandleFunc("GET /api/users/{id}/followers", apiCfg.getFollowersHandler)
+	serverMux.HandleFunc("GET /api/users/{id}/following", apiCfg.getFollowingHandler)
 	serverMux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.deleteChirpById)
-	serverMux.HandleFunc("POST /api/polka/webhooks", apiCfg.upgradeUser)
+	serverMux.HandleFunc("PUT /api/chirps/{id}", apiCfg.updateChirpHandler)
+	serverMux.HandleFunc("POST /api/chirps/{id}/restore", apiCfg.restoreChirpHandler)
+	serverMux.HandleFunc("GET /api/chirps/{id}/history", apiCfg.getChirpHistoryHandler)
+	serverMux.HandleFunc("GET /api/chirps/{id}/thread", apiCfg.getChirpThreadHandler)
+	serverMux.HandleFunc("POST /api/chirps/{id}/reactions", apiCfg.addReactionHandler)
+	serverMux.HandleFunc("DELETE /api/chirps/{id}/reactions", apiCfg.deleteReactionHandler)
+	serverMux.HandleFunc("POST /api/chirps/{id}/rechirp", apiCfg.rechirpHandler)
+	serverMux.HandleFunc("DELETE /api/chirps/{id}/rechirp", apiCfg.undoRechirpHandler)
+	serverMux.HandleFunc("POST /api/chirps/{id}/bookmark", apiCfg.addBookmarkHandler)
+	serverMux.HandleFunc("DELETE /api/chirps/{id}/bookmark", apiCfg.deleteBookmarkHandler)
+	serverMux.HandleFunc("GET /api/bookmarks", apiCfg.getBookmarksHandler)
+	serverMux.HandleFunc("GET /api/chirps/{id}/stats", apiCfg.chirpStatsHandler)
+	serverMux.HandleFunc("PUT /api/chirps/{id}/warning", apiCfg.setContentWarningHandler)
+	serverMux.HandleFunc("DELETE /api/chirps/{id}/warning", apiCfg.deleteContentWarningHandler)
+	serverMux.HandleFunc("POST /api/chirps/{id}/pin", apiCfg.pinChirpHandler)
+	serverMux.HandleFunc("DELETE /api/chirps/{id}/pin", apiCfg.unpinChirpHandler)
+	serverMux.HandleFunc("POST /api/chirps/{id}/poll/votes", apiCfg.votePollHandler)
+	serverMux.HandleFunc("PUT /api/chirps/{id}/schedule", apiCfg.rescheduleChirpHandler)
+	serverMux.HandleFunc("DELETE /api/chirps/{id}/schedule", apiCfg.cancelScheduledChirpHandler)
+	serverMux.HandleFunc("POST /api/drafts", apiCfg.createDraftHandler)
+	serverMux.HandleFunc("GET /api/drafts", apiCfg.getDraftsHandler)
+	serverMux.HandleFunc("GET /api/drafts/{id}", apiCfg.getDraftHandler)
+	serverMux.HandleFunc("PUT /api/drafts/{id}", apiCfg.updateDraftHandler)
+	serverMux.HandleFunc("DELETE /api/drafts/{id}", apiCfg.deleteDraftHandler)
+	serverMux.HandleFunc("POST /api/drafts/{id}/publish", apiCfg.publishDraftHandler)
+	serverMux.HandleFunc("POST /api/polka/webhooks", apiCfg.idempotent(apiCfg.upgradeUser))
+	serverMux.HandleFunc("GET /api/tags/trending", apiCfg.getTrendingTagsHandler)
+	serverMux.HandleFunc("GET /api/tags/{tag}/chirps", apiCfg.getTagChirpsHandler)
 
 	newServer := &http.Server{
 		Addr:    port,
@@ -79,3 +160,31 @@ func main() {
 	log.Printf("Starting server on port %s", port)
 	log.Fatal(newServer.ListenAndServe())
 }
+
+// Reads an optional positive number from the environment
+func intEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Fatalf("Invalid %s: %q", name, value)
+	}
+	return n
+}
+
+// Reads an optional duration such as "30s" or "24h" from the environment
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("Invalid %s: %q", name, value)
+	}
+	return d
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxChirpImages   = 4
+	maxImageSize     = 5 << 20
+	maxAltTextLength = 1000
+)
+
+// Image types we accept and the file extension they are stored with
+var allowedImageTypes = map[string]string{
+	"image/gif":  ".gif",
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// Image attached to a chirp
+type ChirpMedia struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	AltText     string `json:"alt_text"`
+}
+
+// Image uploaded with a new chirp that passed validation
+type imageUpload struct {
+	file        *multipart.FileHeader
+	contentType string
+	altText     string
+}
+
+// Image written to storage, waiting to be linked to its chirp
+type storedImage struct {
+	imageUpload
+	key string
+}
+
+func writeChirpError(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// Reads a chirp sent as multipart/form-data: the chirp fields as form values
+// plus up to four "images" files, each with an optional "alt_text" value in
+// the same order. The body goes through the same checks as a JSON chirp.
+// When it returns false the error response has already been written.
+func multipartHandlerForChirp(w http.ResponseWriter, r *http.Request, maxLength int) (ChirpParam, []imageUpload, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxChirpImages*maxImageSize+1<<20)
+	err := r.ParseMultipartForm(1 << 20)
+	if err != nil {
+		log.Printf("Error parsing multipart form: %v", err)
+		writeChirpError(w, "Invalid multipart form")
+		return ChirpParam{}, nil, false
+	}
+
+	data := ChirpParam{Body: r.FormValue("body")}
+	for field, id := range map[string]*uuid.NullUUID{"parent_id": &data.ParentID, "quote_of_id": &data.QuoteOfID} {
+		if value := r.FormValue(field); value != "" {
+			parsed, err := uuid.Parse(value)
+			if err != nil {
+				writeChirpError(w, "Invalid "+field)
+				return ChirpParam{}, nil, false
+			}
+			*id = uuid.NullUUID{UUID: parsed, Valid: true}
+		}
+	}
+
+	if value := r.FormValue("publish_at"); value != "" {
+		publishAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeChirpError(w, "Invalid publish_at")
+			return ChirpParam{}, nil, false
+		}
+		data.PublishAt = &publishAt
+	}
+
+	data.Visibility = r.FormValue("visibility")
+	data.ContentWarning = r.FormValue("content_warning")
+
+	// The poll is sent as JSON, the same object a JSON chirp has
+	if value := r.FormValue("poll"); value != "" {
+		var poll PollParam
+		if err := json.Unmarshal([]byte(value), &poll); err != nil {
+			writeChirpError(w, "Invalid poll")
+			return ChirpParam{}, nil, false
+		}
+		data.Poll = &poll
+	}
+
+	files := r.MultipartForm.File["images"]
+	if len(files) > maxChirpImages {
+		writeChirpError(w, fmt.Sprintf("A chirp can have at most %d images", maxChirpImages))
+		return ChirpParam{}, nil, false
+	}
+
+	altTexts := r.MultipartForm.Value["alt_text"]
+	var uploads []imageUpload
+	for i, file := range files {
+		if file.Size > maxImageSize {
+			writeChirpError(w, fmt.Sprintf("Images can be at most %d MB", maxImageSize>>20))
+			return ChirpParam{}, nil, false
+		}
+
+		contentType, err := detectImageType(file)
+		if err != nil {
+			writeChirpError(w, err.Error())
+			return ChirpParam{}, nil, false
+		}
+
+		altText := ""
+		if i < len(altTexts) {
+			altText = altTexts[i]
+		}
+		if utf8.RuneCountInString(altText) > maxAltTextLength {
+			writeChirpError(w, fmt.Sprintf("Alt text can be at most %d characters", maxAltTextLength))
+			return ChirpParam{}, nil, false
+		}
+
+		uploads = append(uploads, imageUpload{
+			file:        file,
+			contentType: contentType,
+			altText:     altText,
+		})
+	}
+
+	data, ok := validateChirp(w, data, maxLength)
+	return data, uploads, ok
+}
+
+// Works out an image's type from its content rather than trusting the
+// Content-Type the client sent
+func detectImageType(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("could not read %s", header.Filename)
+	}
+
+	contentType := http.DetectContentType(sniff[:n])
+	if _, ok := allowedImageTypes[contentType]; !ok {
+		return "", fmt.Errorf("%s is not a GIF, JPEG, PNG or WebP image", header.Filename)
+	}
+
+	return contentType, nil
+}
+
+// Writes uploads to storage. If one fails the ones already written are
+// removed again.
+func (a *apiConfig) storeImages(ctx context.Context, uploads []imageUpload) ([]storedImage, error) {
+	var stored []storedImage
+	for _, upload := range uploads {
+		key := uuid.NewString() + allowedImageTypes[upload.contentType]
+
+		err := a.saveUpload(ctx, key, upload.file)
+		if err != nil {
+			a.deleteStoredImages(ctx, stored)
+			return nil, err
+		}
+
+		stored = append(stored, storedImage{imageUpload: upload, key: key})
+	}
+
+	return stored, nil
+}
+
+func (a *apiConfig) saveUpload(ctx context.Context, key string, header *multipart.FileHeader) error {
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return a.media.Save(ctx, key, file)
+}
+
+func (a *apiConfig) deleteStoredImages(ctx context.Context, images []storedImage) {
+	for _, image := range images {
+		if err := a.media.Delete(ctx, image.key); err != nil {
+			log.Printf("Error deleting media %s: %v", image.key, err)
+		}
+	}
+}
+
+// Links stored images to their chirp, in the order they were uploaded. Runs
+// inside the transaction that creates the chirp.
+func saveChirpMedia(ctx context.Context, q *database.Queries, chirpID uuid.UUID, images []storedImage) error {
+	for i, image := range images {
+		_, err := q.CreateChirpMedia(ctx, database.CreateChirpMediaParams{
+			ChirpID:     chirpID,
+			Position:    int32(i),
+			StorageKey:  image.key,
+			ContentType: image.contentType,
+			SizeBytes:   image.file.Size,
+			AltText:     image.altText,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Removes the files of media rows that are gone from the database
+func (a *apiConfig) deleteMediaFiles(ctx context.Context, media []database.ChirpMedium) {
+	for _, medium := range media {
+		if err := a.media.Delete(ctx, medium.StorageKey); err != nil {
+			log.Printf("Error deleting media %s: %v", medium.StorageKey, err)
+		}
+	}
+}
+
+// Serves an uploaded image. Images are only as visible as their chirp, so a
+// deleted chirp's images or those of a chirp the caller can't see aren't
+// found.
+func (a *apiConfig) getMediaHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	viewer, err := a.optionalUserID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	medium, err := a.dbQueries.GetMediaByStorageKey(r.Context(), key)
+	if err == nil {
+		err = a.checkCanView(r.Context(), medium.Chirp, viewer)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	file, err := a.media.Open(r.Context(), key)
+	if errors.Is(err, fs.ErrNotExist) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", medium.ContentType)
+	w.Header().Add("Vary", "Authorization")
+	if medium.Chirp.Visibility != visibilityPublic && medium.Chirp.Visibility != visibilityUnlisted {
+		w.Header().Set("Cache-Control", "private, no-cache")
+	}
+	// Keys are never reused, so there is no modification time worth sending
+	http.ServeContent(w, r, key, time.Time{}, file)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// Linkable part of a chirp's body. Start and End count characters (Unicode
+// code points) and End is exclusive.
+type ChirpEntity struct {
+	Type   string    `json:"type"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+	Handle string    `json:"handle"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// Stores the @mentions in a chirp that belong to a user. Handles nobody has
+// are left alone as plain text. Like saveHashtags it runs inside the
+// transaction that writes the chirp.
+func saveMentions(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	mentions := internal.ExtractMentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	handles := make([]string, 0, len(mentions))
+	for _, mention := range mentions {
+		handles = append(handles, mention.Handle)
+	}
+
+	users, err := q.GetUsersByHandles(ctx, handles)
+	if err != nil {
+		return err
+	}
+	userIDs := make(map[string]uuid.UUID, len(users))
+	for _, user := range users {
+		userIDs[user.Handle.String] = user.ID
+	}
+
+	for _, mention := range mentions {
+		userID, ok := userIDs[mention.Handle]
+		if !ok {
+			continue
+		}
+
+		err = q.CreateMention(ctx, database.CreateMentionParams{
+			ChirpID:    chirp.ID,
+			UserID:     userID,
+			Handle:     mention.Handle,
+			StartIndex: int32(mention.Start),
+			EndIndex:   int32(mention.End),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
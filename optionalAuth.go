@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/google/uuid"
+)
+
+// Identifies the caller on endpoints that also work without logging in.
+// Requests without an Authorization header are anonymous, but a token that
+// is sent has to be valid.
+func (a *apiConfig) optionalUserID(r *http.Request) (uuid.NullUUID, error) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+
+	return uuid.NullUUID{UUID: userID, Valid: true}, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// Page of chirps returned by the listing endpoints
+type ChirpPage struct {
+	Chirps     []Chirp `json:"chirps"`
+	NextCursor *string `json:"next_cursor"`
+	PrevCursor *string `json:"prev_cursor"`
+}
+
+// pageCursor marks the row a page starts after (or before, when paging back).
+// Clients only ever see it base64 encoded so they treat it as opaque.
+type pageCursor struct {
+	// Only set when paging through search results ranked by relevance
+	Rank      float32   `json:"r,omitempty"`
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	Before    bool      `json:"b,omitempty"`
+}
+
+// Position of a chirp in a listing ordered by creation time
+func chirpPosition(chirp database.Chirp) pageCursor {
+	return pageCursor{CreatedAt: chirp.CreatedAt, ID: chirp.ID}
+}
+
+type pageRequest struct {
+	Limit  int32
+	Desc   bool
+	Cursor *pageCursor
+}
+
+func encodeCursor(c pageCursor) *string {
+	data, _ := json.Marshal(c)
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return &encoded
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil {
+		return nil, errors.New("invalid cursor")
+	}
+
+	return &c, nil
+}
+
+// Reads limit, sort and cursor from the query string
+func parsePageRequest(query url.Values) (pageRequest, error) {
+	page := pageRequest{Limit: defaultPageSize}
+
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxPageSize {
+			return pageRequest{}, errors.New("limit must be between 1 and " + strconv.Itoa(maxPageSize))
+		}
+		page.Limit = int32(n)
+	}
+
+	switch query.Get("sort") {
+	case "", "asc":
+	case "desc":
+		page.Desc = true
+	default:
+		return pageRequest{}, errors.New("sort must be asc or desc")
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		c, err := decodeCursor(cursor)
+		if err != nil {
+			return pageRequest{}, err
+		}
+		page.Cursor = c
+	}
+
+	return page, nil
+}
+
+// ascending reports whether rows must be read in ascending order to serve the
+// page. Paging backwards reads the opposite way and buildPage flips the result.
+func (p pageRequest) ascending() bool {
+	if p.Cursor != nil && p.Cursor.Before {
+		return p.Desc
+	}
+	return !p.Desc
+}
+
+// Cursor position as query arguments, both invalid on the first page
+func (p pageRequest) cursorArgs() (sql.NullTime, uuid.NullUUID) {
+	if p.Cursor == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: p.Cursor.CreatedAt, Valid: true}, uuid.NullUUID{UUID: p.Cursor.ID, Valid: true}
+}
+
+// Cursor rank as a query argument, invalid on the first page
+func (p pageRequest) cursorRank() sql.NullFloat64 {
+	if p.Cursor == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: float64(p.Cursor.Rank), Valid: true}
+}
+
+// buildPage trims rows fetched with a limit of p.Limit+1, puts them in the
+// requested order and works out the cursors for the neighbouring pages. key
+// gives the position of a row.
+func buildPage[T any](p pageRequest, rows []T, key func(T) pageCursor) ([]T, *string, *string) {
+	hasMore := len(rows) > int(p.Limit)
+	if hasMore {
+		rows = rows[:p.Limit]
+	}
+
+	backwards := p.Cursor != nil && p.Cursor.Before
+	if backwards {
+		slices.Reverse(rows)
+	}
+
+	if len(rows) == 0 {
+		return rows, nil, nil
+	}
+
+	var next, prev *string
+	if hasMore || backwards {
+		next = encodeCursor(key(rows[len(rows)-1]))
+	}
+	if backwards && hasMore || !backwards && p.Cursor != nil {
+		first := key(rows[0])
+		first.Before = true
+		prev = encodeCursor(first)
+	}
+
+	return rows, next, prev
+}
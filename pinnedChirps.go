@@ -0,0 +1,146 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// How many chirps a user can have pinned to their profile at once
+const (
+	maxPinnedChirps    = 1
+	maxPinnedChirpsRed = 3
+)
+
+func (a *apiConfig) pinChirpHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, userID, ok := a.pinRequest(w, r)
+	if !ok {
+		return
+	}
+
+	tx, err := a.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := a.dbQueries.WithTx(tx)
+
+	// Locking the user makes concurrent pins take turns, so they can't both
+	// squeeze under the limit
+	user, err := qtx.GetUserByIdForUpdate(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	chirp, err := qtx.GetChirpById(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if chirp.UserID != userID {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	if chirp.RechirpOfID.Valid {
+		http.Error(w, "Rechirps can't be pinned", http.StatusBadRequest)
+		return
+	}
+
+	if !chirp.Published {
+		http.Error(w, "Scheduled chirps can't be pinned before they are published", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := qtx.PinChirp(r.Context(), database.PinChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Pinning a chirp that is already pinned changes nothing
+	if rows > 0 {
+		pinned, err := qtx.CountPinnedChirps(r.Context(), userID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		limit := maxPinnedChirps
+		if user.IsChirpyRed {
+			limit = maxPinnedChirpsRed
+		}
+		if pinned > int64(limit) {
+			http.Error(w, fmt.Sprintf("You can pin at most %d chirps, unpin one first", limit), http.StatusConflict)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiConfig) unpinChirpHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, userID, ok := a.pinRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := a.dbQueries.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Reads the chirp ID and the caller from a pin request. When it returns
+// false the error response has already been written.
+func (a *apiConfig) pinRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return chirpID, userID, true
+}
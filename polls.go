@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// Poll sent along with a new chirp
+type PollParam struct {
+	Options  []string  `json:"options"`
+	ClosesAt time.Time `json:"closes_at"`
+}
+
+// Poll attached to a chirp. Vote counts are nil until the caller has voted
+// or the poll has closed, so early results can't sway anyone.
+type Poll struct {
+	Options       []PollOption  `json:"options"`
+	ClosesAt      time.Time     `json:"closes_at"`
+	Closed        bool          `json:"closed"`
+	TotalVotes    *int64        `json:"total_votes"`
+	VotedOptionID uuid.NullUUID `json:"voted_option_id"`
+}
+
+type PollOption struct {
+	ID    uuid.UUID `json:"id"`
+	Text  string    `json:"text"`
+	Votes *int64    `json:"votes"`
+}
+
+type VoteParam struct {
+	OptionID uuid.UUID `json:"option_id"`
+}
+
+// Checks a poll sent with a new chirp and tidies up its options. The poll
+// has to stay open for a while after the chirp is published.
+func validatePoll(poll *PollParam, publishAt *time.Time) error {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return fmt.Errorf("A poll needs %d to %d options", minPollOptions, maxPollOptions)
+	}
+
+	for i, option := range poll.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return errors.New("Poll options can't be empty")
+		}
+		if utf8.RuneCountInString(option) > maxPollOptionLength {
+			return fmt.Errorf("Poll options can be at most %d characters", maxPollOptionLength)
+		}
+		poll.Options[i] = option
+	}
+
+	return checkPollWindow(poll.ClosesAt, publishAt)
+}
+
+// Checks that a poll closing at closesAt stays open long enough, but not too
+// long, after its chirp is published at publishAt (now when nil)
+func checkPollWindow(closesAt time.Time, publishAt *time.Time) error {
+	opensAt := time.Now()
+	if publishAt != nil {
+		opensAt = *publishAt
+	}
+	duration := closesAt.Sub(opensAt)
+	if duration < minPollDuration || duration > maxPollDuration {
+		return errors.New("A poll has to close between 5 minutes and 7 days after the chirp is published")
+	}
+
+	return nil
+}
+
+// Stores a chirp's poll. Runs inside the transaction that creates the chirp.
+func savePoll(ctx context.Context, q *database.Queries, chirpID uuid.UUID, poll *PollParam) error {
+	err := q.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:  chirpID,
+		ClosesAt: poll.ClosesAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, option := range poll.Options {
+		err := q.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID:  chirpID,
+			Position: int32(i),
+			Text:     option,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Loads the polls of the given chirps as viewer sees them, keyed by chirp ID.
+// Chirps without a poll are left out.
+func (a *apiConfig) pollsForChirps(ctx context.Context, chirpIDs []uuid.UUID, viewer uuid.NullUUID) (map[uuid.UUID]*Poll, error) {
+	options, err := a.dbQueries.GetPollOptionsForChirps(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	polls := make(map[uuid.UUID]*Poll)
+	if len(options) == 0 {
+		return polls, nil
+	}
+
+	votes := make(map[uuid.UUID]uuid.UUID)
+	if viewer.Valid {
+		viewerVotes, err := a.dbQueries.GetPollVotesByUser(ctx, database.GetPollVotesByUserParams{
+			UserID:   viewer.UUID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, vote := range viewerVotes {
+			votes[vote.ChirpID] = vote.OptionID
+		}
+	}
+
+	totals := make(map[uuid.UUID]int64)
+	for _, option := range options {
+		poll, ok := polls[option.ChirpID]
+		if !ok {
+			poll = &Poll{
+				Options:  []PollOption{},
+				ClosesAt: option.ClosesAt,
+				Closed:   !time.Now().UTC().Before(option.ClosesAt),
+			}
+			if optionID, voted := votes[option.ChirpID]; voted {
+				poll.VotedOptionID = uuid.NullUUID{UUID: optionID, Valid: true}
+			}
+			polls[option.ChirpID] = poll
+		}
+
+		pollOption := PollOption{ID: option.ID, Text: option.Text}
+		if poll.Closed || poll.VotedOptionID.Valid {
+			count := option.VoteCount
+			pollOption.Votes = &count
+		}
+		poll.Options = append(poll.Options, pollOption)
+		totals[option.ChirpID] += option.VoteCount
+	}
+
+	for chirpID, poll := range polls {
+		if poll.Closed || poll.VotedOptionID.Valid {
+			total := totals[chirpID]
+			poll.TotalVotes = &total
+		}
+	}
+
+	return polls, nil
+}
+
+func (a *apiConfig) votePollHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var param VoteParam
+	err = json.NewDecoder(r.Body).Decode(&param)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	chirp, err := a.dbQueries.GetChirpById(r.Context(), chirpID)
+	if err == nil {
+		err = a.checkCanView(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	option, err := a.dbQueries.GetPollOption(r.Context(), database.GetPollOptionParams{
+		ID:      param.OptionID,
+		ChirpID: chirpID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Not an option of this chirp's poll", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if !time.Now().UTC().Before(option.ClosesAt) {
+		http.Error(w, "Poll is closed", http.StatusConflict)
+		return
+	}
+
+	rows, err := a.dbQueries.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		ChirpID:  chirpID,
+		UserID:   userID,
+		OptionID: option.ID,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		http.Error(w, "You already voted in this poll", http.StatusConflict)
+		return
+	}
+
+	// Voting reveals the results, so send them straight back
+	polls, err := a.pollsForChirps(r.Context(), []uuid.UUID{chirpID}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(polls[chirpID])
+}
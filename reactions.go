@@ -0,0 +1,139 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// Used when CHIRP_REACTIONS is not set
+var defaultReactions = []string{"👍", "❤️", "😂", "😮", "😢", "🎉"}
+
+// Aggregated reactions of one emoji on a chirp
+type ReactionCount struct {
+	Emoji   string `json:"emoji"`
+	Count   int64  `json:"count"`
+	Reacted bool   `json:"reacted"`
+}
+
+type ReactionParam struct {
+	Emoji string `json:"emoji"`
+}
+
+// Parses the comma separated list of emoji users may react with
+func parseReactions(value string) []string {
+	if value == "" {
+		return defaultReactions
+	}
+
+	var reactions []string
+	for _, emoji := range strings.Split(value, ",") {
+		emoji = strings.TrimSpace(emoji)
+		if emoji != "" {
+			reactions = append(reactions, emoji)
+		}
+	}
+	return reactions
+}
+
+func (a *apiConfig) addReactionHandler(w http.ResponseWriter, r *http.Request) {
+	var param ReactionParam
+	chirpID, userID, ok := a.reactionRequest(w, r, &param)
+	if !ok {
+		return
+	}
+
+	_, err := a.dbQueries.CreateReaction(r.Context(), database.CreateReactionParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+		Emoji:   param.Emoji,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *apiConfig) deleteReactionHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, userID, ok := a.reactionRequest(w, r, nil)
+	if !ok {
+		return
+	}
+
+	deleted, err := a.dbQueries.DeleteReaction(r.Context(), database.DeleteReactionParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+		Emoji:   r.URL.Query().Get("emoji"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Checks everything both reaction endpoints need: the chirp ID, the caller
+// and, when param is not nil, a JSON body with an allowed emoji. When it
+// returns false the error response has already been written.
+func (a *apiConfig) reactionRequest(w http.ResponseWriter, r *http.Request, param *ReactionParam) (uuid.UUID, uuid.UUID, bool) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if param != nil {
+		err = json.NewDecoder(r.Body).Decode(param)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return uuid.Nil, uuid.Nil, false
+		}
+
+		if !slices.Contains(a.reactions, param.Emoji) {
+			http.Error(w, "Reaction must be one of "+strings.Join(a.reactions, " "), http.StatusBadRequest)
+			return uuid.Nil, uuid.Nil, false
+		}
+	}
+
+	chirp, err := a.dbQueries.GetChirpById(r.Context(), chirpID)
+	if err == nil {
+		err = a.checkCanView(r.Context(), chirp, uuid.NullUUID{UUID: userID, Valid: true})
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return chirpID, userID, true
+}
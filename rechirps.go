@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// Looks up the chirp being rechirped or quoted. A plain rechirp has no content
+// of its own, so amplifying one amplifies the chirp it points at instead.
+// Scheduled chirps are treated as missing until they are published, and
+// only chirps anyone may see can be amplified.
+func (a *apiConfig) originalChirp(ctx context.Context, chirpID uuid.UUID) (database.Chirp, error) {
+	chirp, err := a.dbQueries.GetChirpById(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if !canView(chirp, uuid.NullUUID{}, false) {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+
+	if chirp.RechirpOfID.Valid {
+		return a.dbQueries.GetChirpById(ctx, chirp.RechirpOfID.UUID)
+	}
+
+	return chirp, nil
+}
+
+func (a *apiConfig) rechirpHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	original, err := a.originalChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rechirp, err := a.dbQueries.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:      userID,
+		RechirpOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	// Nothing is returned when the user has already rechirped it
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Chirp already rechirped", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := a.chirpsResponse(r.Context(), []database.Chirp{rechirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response[0])
+}
+
+func (a *apiConfig) undoRechirpHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	original, err := a.originalChirp(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	deleted, err := a.dbQueries.DeleteRechirp(r.Context(), database.DeleteRechirpParams{
+		UserID:      userID,
+		RechirpOfID: uuid.NullUUID{UUID: original.ID, Valid: true},
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
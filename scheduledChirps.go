@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPublishInterval = 30 * time.Second
+	maxScheduleAhead       = 365 * 24 * time.Hour
+)
+
+type ScheduleParam struct {
+	PublishAt time.Time `json:"publish_at"`
+}
+
+func validatePublishAt(publishAt time.Time) error {
+	if !publishAt.After(time.Now()) {
+		return errors.New("publish_at must be in the future")
+	}
+	if publishAt.After(time.Now().Add(maxScheduleAhead)) {
+		return errors.New("publish_at can be at most a year ahead")
+	}
+	return nil
+}
+
+// Flips chirps whose time has come to published. Run periodically from main.
+func (a *apiConfig) publishDueChirps(ctx context.Context) error {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := a.dbQueries.WithTx(tx)
+
+	// Published chirps take their scheduled time as created_at so they show
+	// up in feeds where the author meant them to
+	chirps, err := qtx.PublishDueChirps(ctx)
+	if err != nil {
+		return err
+	}
+	if len(chirps) == 0 {
+		return nil
+	}
+
+	chirpIDs := make([]uuid.UUID, 0, len(chirps))
+	for _, chirp := range chirps {
+		chirpIDs = append(chirpIDs, chirp.ID)
+	}
+
+	// Trending counts hashtags by when their chirp went out, not when it was written
+	err = qtx.SyncChirpTagTimes(ctx, chirpIDs)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("Published %d scheduled chirps", len(chirps))
+	return nil
+}
+
+func (a *apiConfig) getScheduledChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	chirps, err := a.dbQueries.ListScheduledChirps(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := a.chirpsResponse(r.Context(), chirps, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (a *apiConfig) rescheduleChirpHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, userID, ok := a.scheduledChirpRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var param ScheduleParam
+	err := json.NewDecoder(r.Body).Decode(&param)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := validatePublishAt(param.PublishAt); err != nil {
+		writeChirpError(w, err.Error())
+		return
+	}
+
+	// A poll closes at a fixed time, so moving the chirp mustn't leave it
+	// closing too soon after, or already before, the chirp goes out
+	poll, err := a.dbQueries.GetPollByChirpId(r.Context(), chirpID)
+	if err == nil {
+		err = checkPollWindow(poll.ClosesAt, &param.PublishAt)
+		if err != nil {
+			writeChirpError(w, err.Error())
+			return
+		}
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Only matches while the chirp is still pending, so a chirp the publisher
+	// got to first isn't moved
+	chirp, err := a.dbQueries.RescheduleChirp(r.Context(), database.RescheduleChirpParams{
+		PublishAt: sql.NullTime{Time: param.PublishAt.UTC(), Valid: true},
+		ID:        chirpID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Chirp is already published", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := a.chirpsResponse(r.Context(), []database.Chirp{chirp}, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response[0])
+}
+
+func (a *apiConfig) cancelScheduledChirpHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, _, ok := a.scheduledChirpRequest(w, r)
+	if !ok {
+		return
+	}
+
+	media, err := a.dbQueries.GetMediaForChirps(r.Context(), []uuid.UUID{chirpID})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := a.dbQueries.DeleteScheduledChirp(r.Context(), chirpID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if rows == 0 {
+		http.Error(w, "Chirp is already published", http.StatusConflict)
+		return
+	}
+
+	a.deleteMediaFiles(r.Context(), media)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Shared checks for the endpoints that manage a pending chirp: the caller
+// must be logged in, own the chirp, and it must not be published yet. When
+// it returns false the error response has already been written.
+func (a *apiConfig) scheduledChirpRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	tokenString, err := internal.GetBearerToken(r.Header)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := internal.ValidateJWT(tokenString, a.secret)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	chirp, err := a.dbQueries.GetChirpById(r.Context(), chirpID)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return uuid.Nil, uuid.Nil, false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if chirp.UserID != userID {
+		w.WriteHeader(http.StatusForbidden)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if chirp.Published {
+		http.Error(w, "Chirp is already published", http.StatusConflict)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return chirpID, userID, true
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// Full-text search over chirp bodies. q follows websearch syntax, so
+// "quoted words" match as a phrase, "or" gives alternatives and -word
+// excludes a word. Results are paginated like GET /api/chirps.
+func (a *apiConfig) searchChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	viewer, err := a.optionalUserID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+
+	searchQuery := strings.TrimSpace(query.Get("q"))
+	if searchQuery == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+
+	rank := query.Get("rank")
+	if rank == "" {
+		rank = "relevance"
+	}
+	if rank != "relevance" && rank != "recency" {
+		http.Error(w, "rank must be relevance or recency", http.StatusBadRequest)
+		return
+	}
+
+	authorID := uuid.NullUUID{}
+	if param := query.Get("author_id"); param != "" {
+		userId, err := uuid.Parse(param)
+		if err != nil {
+			http.Error(w, "Invalid author_id", http.StatusBadRequest)
+			return
+		}
+		authorID = uuid.NullUUID{UUID: userId, Valid: true}
+	}
+
+	page, err := parsePageRequest(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var chirps []database.Chirp
+	var next, prev *string
+	cursorCreatedAt, cursorID := page.cursorArgs()
+
+	if rank == "relevance" {
+		// Best match first, sort doesn't apply
+		page.Desc = true
+
+		searchParams := database.SearchChirpsByRelevanceDescParams{
+			Query:           searchQuery,
+			AuthorID:        authorID,
+			ViewerID:        viewer,
+			CursorRank:      page.cursorRank(),
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.Limit + 1,
+		}
+
+		var rows []database.SearchChirpsByRelevanceDescRow
+		if page.ascending() {
+			var ascRows []database.SearchChirpsByRelevanceAscRow
+			ascRows, err = a.dbQueries.SearchChirpsByRelevanceAsc(r.Context(), database.SearchChirpsByRelevanceAscParams(searchParams))
+			for _, row := range ascRows {
+				rows = append(rows, database.SearchChirpsByRelevanceDescRow(row))
+			}
+		} else {
+			rows, err = a.dbQueries.SearchChirpsByRelevanceDesc(r.Context(), searchParams)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		rows, next, prev = buildPage(page, rows, func(row database.SearchChirpsByRelevanceDescRow) pageCursor {
+			return pageCursor{Rank: row.Rank, CreatedAt: row.Chirp.CreatedAt, ID: row.Chirp.ID}
+		})
+		for _, row := range rows {
+			chirps = append(chirps, row.Chirp)
+		}
+	} else {
+		// Newest first unless asked otherwise
+		if query.Get("sort") == "" {
+			page.Desc = true
+		}
+
+		searchParams := database.SearchChirpsByRecencyAscParams{
+			Query:           searchQuery,
+			AuthorID:        authorID,
+			ViewerID:        viewer,
+			CursorCreatedAt: cursorCreatedAt,
+			CursorID:        cursorID,
+			PageSize:        page.Limit + 1,
+		}
+
+		if page.ascending() {
+			chirps, err = a.dbQueries.SearchChirpsByRecencyAsc(r.Context(), searchParams)
+		} else {
+			chirps, err = a.dbQueries.SearchChirpsByRecencyDesc(r.Context(), database.SearchChirpsByRecencyDescParams(searchParams))
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		chirps, next, prev = buildPage(page, chirps, chirpPosition)
+	}
+
+	chirpsSet, err := a.chirpsResponse(r.Context(), chirps, viewer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ChirpPage{
+		Chirps:     chirpsSet,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
+}
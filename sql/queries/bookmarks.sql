@@ -0,0 +1,56 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarkedChirpIds :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg('user_id') AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: ListBookmarksAsc :many
+-- A bookmark doesn't keep a chirp visible once the user can no longer see
+-- it, like after unfollowing the author of a followers-only chirp
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg('user_id') AND chirps.published AND chirps.deleted_at IS NULL
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = sqlc.arg('user_id')
+    OR (chirps.visibility = 'followers' AND chirps.user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.arg('user_id')
+    ))
+)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (bookmarks.created_at, chirps.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY bookmarks.created_at ASC, chirps.id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListBookmarksDesc :many
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = sqlc.arg('user_id') AND chirps.published AND chirps.deleted_at IS NULL
+AND (
+    chirps.visibility IN ('public', 'unlisted')
+    OR chirps.user_id = sqlc.arg('user_id')
+    OR (chirps.visibility = 'followers' AND chirps.user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.arg('user_id')
+    ))
+)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (bookmarks.created_at, chirps.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY bookmarks.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
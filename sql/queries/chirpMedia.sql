@@ -0,0 +1,26 @@
+-- name: CreateChirpMedia :one
+INSERT INTO chirp_media (id, created_at, chirp_id, position, storage_key, content_type, size_bytes, alt_text)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: GetMediaForChirps :many
+SELECT * FROM chirp_media
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: GetMediaByStorageKey :one
+-- Finds an image with the chirp it belongs to, so it is only served to those
+-- who can see the chirp
+SELECT sqlc.embed(chirps), chirp_media.content_type
+FROM chirp_media
+JOIN chirps ON chirps.id = chirp_media.chirp_id
+WHERE chirp_media.storage_key = $1 AND chirps.deleted_at IS NULL;
@@ -1,30 +1,316 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, user_id, body)
+INSERT INTO chirps (id, created_at, updated_at, user_id, body, parent_id, root_id, quote_of_id, publish_at, published, visibility, content_warning)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
 )
 RETURNING *;
 
--- name: GetAllChirps :many
-SELECT * FROM chirps
-ORDER BY created_at ASC;
-
 -- name: GetChirpById :one
 SELECT * FROM chirps
-WHERE id = $1;
+WHERE id = $1 AND deleted_at IS NULL;
 
 -- name: DeleteAllChirps :exec
 DELETE FROM chirps;
 
 -- name: DeleteChirpById :exec
+-- Leaves a tombstone so the chirp can be restored. Rechirps of it go with it,
+-- as they did when rows were deleted for real.
+UPDATE chirps
+SET deleted_at = NOW()
+WHERE (id = $1 OR rechirp_of_id = $1) AND deleted_at IS NULL;
+
+-- name: ListChirpsAsc :many
+SELECT * FROM chirps
+WHERE published AND deleted_at IS NULL
+AND (coalesce(cardinality(sqlc.arg('author_ids')::uuid[]), 0) = 0 OR user_id = ANY(sqlc.arg('author_ids')::uuid[]))
+AND (
+    visibility = 'public'
+    OR user_id = sqlc.narg('viewer_id')
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.narg('viewer_id')
+    ))
+)
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+AND (
+    sqlc.narg('has_media')::bool IS NULL
+    OR EXISTS (SELECT 1 FROM chirp_media WHERE chirp_media.chirp_id = chirps.id) = sqlc.narg('has_media')::bool
+)
+AND (sqlc.narg('is_reply')::bool IS NULL OR (parent_id IS NOT NULL) = sqlc.narg('is_reply')::bool)
+AND (sqlc.narg('contains')::text IS NULL OR strpos(lower(body), lower(sqlc.narg('contains')::text)) > 0)
+AND (coalesce(cardinality(sqlc.arg('exclude_ids')::uuid[]), 0) = 0 OR NOT id = ANY(sqlc.arg('exclude_ids')::uuid[]))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE published AND deleted_at IS NULL
+AND (coalesce(cardinality(sqlc.arg('author_ids')::uuid[]), 0) = 0 OR user_id = ANY(sqlc.arg('author_ids')::uuid[]))
+AND (
+    visibility = 'public'
+    OR user_id = sqlc.narg('viewer_id')
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.narg('viewer_id')
+    ))
+)
+AND (sqlc.narg('since')::timestamp IS NULL OR created_at >= sqlc.narg('since')::timestamp)
+AND (sqlc.narg('until')::timestamp IS NULL OR created_at < sqlc.narg('until')::timestamp)
+AND (
+    sqlc.narg('has_media')::bool IS NULL
+    OR EXISTS (SELECT 1 FROM chirp_media WHERE chirp_media.chirp_id = chirps.id) = sqlc.narg('has_media')::bool
+)
+AND (sqlc.narg('is_reply')::bool IS NULL OR (parent_id IS NOT NULL) = sqlc.narg('is_reply')::bool)
+AND (sqlc.narg('contains')::text IS NULL OR strpos(lower(body), lower(sqlc.narg('contains')::text)) > 0)
+AND (coalesce(cardinality(sqlc.arg('exclude_ids')::uuid[]), 0) = 0 OR NOT id = ANY(sqlc.arg('exclude_ids')::uuid[]))
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetChirpByIdForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1 AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET
+    body = $1,
+    updated_at = NOW(),
+    body_updated_at = NOW()
+WHERE
+    id = $2 AND deleted_at IS NULL
+RETURNING *;
+
+-- name: GetThreadChirps :many
+SELECT * FROM chirps
+WHERE root_id = $1 AND published AND deleted_at IS NULL
+ORDER BY created_at ASC, id ASC;
+
+-- name: CountRepliesForChirps :many
+-- Only counts replies the viewer may see, the same ones the thread shows
+SELECT parent_id, COUNT(*) AS reply_count FROM chirps
+WHERE parent_id = ANY(sqlc.arg('chirp_ids')::uuid[]) AND published AND deleted_at IS NULL
+AND (
+    visibility IN ('public', 'unlisted')
+    OR user_id = sqlc.narg('viewer_id')
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.narg('viewer_id')
+    ))
+)
+GROUP BY parent_id;
+
+-- name: GetChirpsByIds :many
+SELECT * FROM chirps
+WHERE id = ANY(sqlc.arg('ids')::uuid[]) AND deleted_at IS NULL;
+
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, user_id, body, rechirp_of_id)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    '',
+    $2
+)
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL AND deleted_at IS NULL DO NOTHING
+RETURNING *;
+
+-- name: DeleteRechirp :execrows
 DELETE FROM chirps
-WHERE id = $1;
+WHERE user_id = $1 AND rechirp_of_id = $2 AND deleted_at IS NULL;
 
--- name: GetAuthorChirps :many
+-- name: SearchChirpsByRelevanceDesc :many
+SELECT sqlc.embed(chirps), ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', sqlc.arg('query')))::real AS rank
+FROM chirps
+WHERE chirps.published AND chirps.deleted_at IS NULL
+AND to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', sqlc.arg('query'))
+AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id'))
+AND (
+    chirps.visibility = 'public'
+    OR chirps.user_id = sqlc.narg('viewer_id')
+    OR (chirps.visibility = 'followers' AND chirps.user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.narg('viewer_id')
+    ))
+)
+AND (
+    sqlc.narg('cursor_rank')::real IS NULL
+    OR (ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', sqlc.arg('query')))::real, chirps.created_at, chirps.id)
+        < (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: SearchChirpsByRelevanceAsc :many
+SELECT sqlc.embed(chirps), ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', sqlc.arg('query')))::real AS rank
+FROM chirps
+WHERE chirps.published AND chirps.deleted_at IS NULL
+AND to_tsvector('english', chirps.body) @@ websearch_to_tsquery('english', sqlc.arg('query'))
+AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id'))
+AND (
+    chirps.visibility = 'public'
+    OR chirps.user_id = sqlc.narg('viewer_id')
+    OR (chirps.visibility = 'followers' AND chirps.user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.narg('viewer_id')
+    ))
+)
+AND (
+    sqlc.narg('cursor_rank')::real IS NULL
+    OR (ts_rank(to_tsvector('english', chirps.body), websearch_to_tsquery('english', sqlc.arg('query')))::real, chirps.created_at, chirps.id)
+        > (sqlc.narg('cursor_rank')::real, sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY rank ASC, chirps.created_at ASC, chirps.id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: SearchChirpsByRecencyAsc :many
 SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC;
\ No newline at end of file
+WHERE published AND deleted_at IS NULL
+AND to_tsvector('english', body) @@ websearch_to_tsquery('english', sqlc.arg('query'))
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+AND (
+    visibility = 'public'
+    OR user_id = sqlc.narg('viewer_id')
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.narg('viewer_id')
+    ))
+)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: SearchChirpsByRecencyDesc :many
+SELECT * FROM chirps
+WHERE published AND deleted_at IS NULL
+AND to_tsvector('english', body) @@ websearch_to_tsquery('english', sqlc.arg('query'))
+AND (sqlc.narg('author_id')::uuid IS NULL OR user_id = sqlc.narg('author_id'))
+AND (
+    visibility = 'public'
+    OR user_id = sqlc.narg('viewer_id')
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.narg('viewer_id')
+    ))
+)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: PublishDueChirps :many
+UPDATE chirps
+SET
+    published = true,
+    created_at = publish_at,
+    updated_at = NOW(),
+    body_updated_at = NOW()
+WHERE
+    NOT published AND publish_at <= NOW() AND deleted_at IS NULL
+RETURNING *;
+
+-- name: ListScheduledChirps :many
+SELECT * FROM chirps
+WHERE user_id = $1 AND NOT published AND deleted_at IS NULL
+ORDER BY publish_at ASC, id ASC;
+
+-- name: RescheduleChirp :one
+UPDATE chirps
+SET
+    publish_at = $1,
+    updated_at = NOW()
+WHERE
+    id = $2 AND NOT published AND deleted_at IS NULL
+RETURNING *;
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM chirps
+WHERE id = $1 AND NOT published AND deleted_at IS NULL;
+
+-- name: GetDeletedChirpById :one
+SELECT * FROM chirps
+WHERE id = $1 AND deleted_at IS NOT NULL;
+
+-- name: RestoreChirp :execrows
+-- Brings back the chirp and the rechirps that were deleted along with it
+UPDATE chirps
+SET deleted_at = NULL
+WHERE (id = sqlc.arg('id') OR rechirp_of_id = sqlc.arg('id')) AND deleted_at = sqlc.arg('deleted_at');
+
+-- name: LockPurgeableChirpMedia :many
+-- Locks the chirps about to be purged so they can't be restored while their
+-- files are being removed
+SELECT chirp_media.storage_key FROM chirp_media
+JOIN chirps ON chirps.id = chirp_media.chirp_id
+WHERE chirps.deleted_at < sqlc.arg('deleted_before')
+FOR UPDATE OF chirps;
+
+-- name: PurgeDeletedChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < sqlc.arg('deleted_before');
+
+-- name: ExportAuthorChirps :many
+-- All of a user's chirps, scheduled ones included, read in batches
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg('user_id') AND deleted_at IS NULL
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ImportChirps :many
+-- Inserts a batch of chirps brought over from elsewhere, keeping the times
+-- they were originally posted at. IDs are picked by the caller so replies in
+-- the same import can point at their parents.
+INSERT INTO chirps (id, created_at, updated_at, body_updated_at, user_id, body, parent_id, root_id, quote_of_id, publish_at, published, visibility, content_warning)
+SELECT
+    imported.id, imported.created_at, imported.created_at, imported.created_at, sqlc.arg('user_id'), imported.body,
+    imported.parent_id, imported.root_id, imported.quote_of_id, imported.publish_at, imported.published, imported.visibility,
+    NULLIF(imported.content_warning, '')
+FROM unnest(
+    sqlc.arg('ids')::uuid[],
+    sqlc.arg('created_ats')::timestamp[],
+    sqlc.arg('bodies')::text[],
+    sqlc.arg('parent_ids')::uuid[],
+    sqlc.arg('root_ids')::uuid[],
+    sqlc.arg('quote_of_ids')::uuid[],
+    sqlc.arg('publish_ats')::timestamp[],
+    sqlc.arg('published')::boolean[],
+    sqlc.arg('visibilities')::text[],
+    sqlc.arg('content_warnings')::text[]
+) AS imported (id, created_at, body, parent_id, root_id, quote_of_id, publish_at, published, visibility, content_warning)
+RETURNING *;
+
+-- name: SetContentWarning :one
+-- Moves updated_at so cached copies are refreshed, but not body_updated_at,
+-- the warning isn't part of the body's revisions
+UPDATE chirps
+SET
+    content_warning = $1,
+    content_warning_by_moderator = $2,
+    updated_at = NOW()
+WHERE
+    id = $3 AND deleted_at IS NULL
+RETURNING *;
@@ -0,0 +1,39 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, parent_id, quote_of_id, visibility)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    NOW(),
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+)
+RETURNING *;
+
+-- name: GetDraftsForUser :many
+SELECT * FROM drafts
+WHERE user_id = $1
+ORDER BY updated_at DESC, id DESC;
+
+-- name: GetDraftById :one
+SELECT * FROM drafts
+WHERE id = $1 AND user_id = $2;
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET
+    body = $1,
+    parent_id = $2,
+    quote_of_id = $3,
+    visibility = $4,
+    updated_at = NOW()
+WHERE
+    id = $5 AND user_id = $6
+RETURNING *;
+
+-- name: DeleteDraft :one
+DELETE FROM drafts
+WHERE id = $1 AND user_id = $2
+RETURNING *;
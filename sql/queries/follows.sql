@@ -0,0 +1,77 @@
+-- name: CreateFollow :execrows
+INSERT INTO follows (follower_id, followed_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (follower_id, followed_id) DO NOTHING;
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followed_id = $2;
+
+-- name: GetFollowedUserIds :many
+SELECT followed_id FROM follows
+WHERE follower_id = sqlc.arg('follower_id') AND followed_id = ANY(sqlc.arg('user_ids')::uuid[]);
+
+-- name: GetFollowCounts :one
+SELECT
+    (SELECT COUNT(*) FROM follows WHERE followed_id = $1) AS followers,
+    (SELECT COUNT(*) FROM follows WHERE follower_id = $1) AS following;
+
+-- name: GetFollowCountsForUsers :many
+SELECT
+    users.id,
+    (SELECT COUNT(*) FROM follows WHERE follows.followed_id = users.id) AS followers,
+    (SELECT COUNT(*) FROM follows WHERE follows.follower_id = users.id) AS following
+FROM users
+WHERE users.id = ANY(sqlc.arg('user_ids')::uuid[]);
+
+-- name: ListFollowersAsc :many
+SELECT users.id, users.handle, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followed_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (follows.created_at, users.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY follows.created_at ASC, users.id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListFollowersDesc :many
+SELECT users.id, users.handle, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.follower_id
+WHERE follows.followed_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (follows.created_at, users.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListFollowingAsc :many
+SELECT users.id, users.handle, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.followed_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (follows.created_at, users.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY follows.created_at ASC, users.id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListFollowingDesc :many
+SELECT users.id, users.handle, follows.created_at AS followed_at
+FROM follows
+JOIN users ON users.id = follows.followed_id
+WHERE follows.follower_id = sqlc.arg('user_id')
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (follows.created_at, users.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY follows.created_at DESC, users.id DESC
+LIMIT sqlc.arg('page_size');
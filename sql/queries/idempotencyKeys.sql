@@ -0,0 +1,38 @@
+-- name: ClaimIdempotencyKey :execrows
+-- Claims a key for a new request. An expired key can be claimed again.
+INSERT INTO idempotency_keys (scope, key, request_hash, created_at)
+VALUES (
+    sqlc.arg('scope'),
+    sqlc.arg('key'),
+    sqlc.arg('request_hash'),
+    NOW()
+)
+ON CONFLICT (scope, key) DO UPDATE
+SET
+    request_hash = EXCLUDED.request_hash,
+    created_at = EXCLUDED.created_at,
+    status_code = NULL,
+    content_type = NULL,
+    response_body = NULL
+WHERE idempotency_keys.created_at < sqlc.arg('expired_before');
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE scope = $1 AND key = $2;
+
+-- name: SaveIdempotentResponse :exec
+UPDATE idempotency_keys
+SET
+    status_code = $1,
+    content_type = $2,
+    response_body = $3
+WHERE
+    scope = $4 AND key = $5;
+
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE scope = $1 AND key = $2;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE created_at < sqlc.arg('expired_before');
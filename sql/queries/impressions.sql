@@ -0,0 +1,44 @@
+-- name: RecordImpressions :exec
+-- Impressions of chirps purged since they were seen are dropped
+INSERT INTO chirp_impressions (chirp_id, viewer_key, bucket)
+SELECT impressions.chirp_id, impressions.viewer_key, impressions.bucket
+FROM unnest(
+    sqlc.arg('chirp_ids')::uuid[],
+    sqlc.arg('viewer_keys')::text[],
+    sqlc.arg('buckets')::timestamp[]
+) AS impressions (chirp_id, viewer_key, bucket)
+WHERE EXISTS (SELECT 1 FROM chirps WHERE chirps.id = impressions.chirp_id)
+ON CONFLICT DO NOTHING;
+
+-- name: GetChirpStats :one
+SELECT
+    (SELECT COUNT(*) FROM chirp_impressions WHERE chirp_impressions.chirp_id = sqlc.arg('chirp_id')) AS impressions,
+    (SELECT COUNT(DISTINCT viewer_key) FROM chirp_impressions WHERE chirp_impressions.chirp_id = sqlc.arg('chirp_id')) AS unique_viewers,
+    (SELECT COUNT(*) FROM reactions WHERE reactions.chirp_id = sqlc.arg('chirp_id')) AS reactions,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.parent_id = sqlc.arg('chirp_id') AND chirps.published AND chirps.deleted_at IS NULL) AS replies,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.rechirp_of_id = sqlc.arg('chirp_id') AND chirps.deleted_at IS NULL) AS rechirps,
+    (SELECT COUNT(*) FROM chirps WHERE chirps.quote_of_id = sqlc.arg('chirp_id') AND chirps.published AND chirps.deleted_at IS NULL) AS quotes,
+    (SELECT COUNT(*) FROM bookmarks WHERE bookmarks.chirp_id = sqlc.arg('chirp_id')) AS bookmarks;
+
+-- name: GetAuthorImpressionTotals :one
+SELECT COUNT(*) AS impressions, COUNT(DISTINCT chirp_impressions.viewer_key) AS unique_viewers
+FROM chirp_impressions
+JOIN chirps ON chirps.id = chirp_impressions.chirp_id
+WHERE chirps.user_id = sqlc.arg('user_id') AND chirps.deleted_at IS NULL
+AND chirp_impressions.bucket >= sqlc.arg('since');
+
+-- name: GetTopAuthorChirps :many
+-- The author's chirps seen the most since the given time
+SELECT
+    chirps.id,
+    chirps.created_at,
+    chirps.body,
+    COUNT(*) AS impressions,
+    COUNT(DISTINCT chirp_impressions.viewer_key) AS unique_viewers
+FROM chirp_impressions
+JOIN chirps ON chirps.id = chirp_impressions.chirp_id
+WHERE chirps.user_id = sqlc.arg('user_id') AND chirps.deleted_at IS NULL
+AND chirp_impressions.bucket >= sqlc.arg('since')
+GROUP BY chirps.id
+ORDER BY impressions DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT sqlc.arg('max_chirps');
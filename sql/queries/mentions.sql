@@ -0,0 +1,18 @@
+-- name: CreateMention :exec
+INSERT INTO mentions (chirp_id, user_id, handle, start_index, end_index)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5
+);
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
+
+-- name: GetMentionsForChirps :many
+SELECT * FROM mentions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, start_index;
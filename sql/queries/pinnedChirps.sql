@@ -0,0 +1,27 @@
+-- name: PinChirp :execrows
+INSERT INTO pinned_chirps (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnpinChirp :execrows
+DELETE FROM pinned_chirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: CountPinnedChirps :one
+SELECT COUNT(*) FROM pinned_chirps
+JOIN chirps ON chirps.id = pinned_chirps.chirp_id
+WHERE pinned_chirps.user_id = $1 AND chirps.deleted_at IS NULL;
+
+-- name: GetPinnedChirps :many
+SELECT chirps.* FROM chirps
+JOIN pinned_chirps ON pinned_chirps.chirp_id = chirps.id
+WHERE pinned_chirps.user_id = $1 AND chirps.deleted_at IS NULL
+ORDER BY pinned_chirps.created_at DESC;
+
+-- name: GetPinnedChirpIds :many
+SELECT chirp_id FROM pinned_chirps
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
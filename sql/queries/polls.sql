@@ -0,0 +1,53 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, closes_at)
+VALUES (
+    $1,
+    NOW(),
+    $2
+);
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (id, chirp_id, position, text)
+VALUES (
+    gen_random_uuid(),
+    $1,
+    $2,
+    $3
+);
+
+-- name: GetPollOptionsForChirps :many
+SELECT
+    poll_options.id,
+    poll_options.chirp_id,
+    poll_options.text,
+    polls.closes_at,
+    COUNT(poll_votes.user_id) AS vote_count
+FROM poll_options
+JOIN polls ON polls.chirp_id = poll_options.chirp_id
+LEFT JOIN poll_votes ON poll_votes.option_id = poll_options.id
+WHERE poll_options.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY poll_options.id, polls.closes_at
+ORDER BY poll_options.chirp_id, poll_options.position;
+
+-- name: GetPollVotesByUser :many
+SELECT chirp_id, option_id FROM poll_votes
+WHERE user_id = sqlc.arg('user_id') AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
+
+-- name: GetPollOption :one
+SELECT poll_options.id, polls.closes_at FROM poll_options
+JOIN polls ON polls.chirp_id = poll_options.chirp_id
+WHERE poll_options.id = $1 AND poll_options.chirp_id = $2;
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (chirp_id, user_id, option_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (chirp_id, user_id) DO NOTHING;
+
+-- name: GetPollByChirpId :one
+SELECT * FROM polls
+WHERE chirp_id = $1;
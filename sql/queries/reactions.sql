@@ -0,0 +1,24 @@
+-- name: CreateReaction :execrows
+INSERT INTO reactions (user_id, chirp_id, emoji, created_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    NOW()
+)
+ON CONFLICT (user_id, chirp_id, emoji) DO NOTHING;
+
+-- name: DeleteReaction :execrows
+DELETE FROM reactions
+WHERE user_id = $1 AND chirp_id = $2 AND emoji = $3;
+
+-- name: CountReactionsForChirps :many
+SELECT
+    chirp_id,
+    emoji,
+    COUNT(*) AS reaction_count,
+    COALESCE(BOOL_OR(user_id = sqlc.narg('viewer_id')::uuid), false)::bool AS reacted
+FROM reactions
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY chirp_id, emoji
+ORDER BY chirp_id, reaction_count DESC, emoji;
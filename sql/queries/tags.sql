@@ -0,0 +1,85 @@
+-- name: UpsertTag :one
+INSERT INTO tags (id, created_at, name)
+VALUES (
+    gen_random_uuid(),
+    NOW(),
+    $1
+)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: AddChirpTag :exec
+INSERT INTO chirp_tags (chirp_id, tag_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (chirp_id, tag_id) DO NOTHING;
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = $1;
+
+-- name: DeleteAllTags :exec
+DELETE FROM tags;
+
+-- name: ListTagChirpsAsc :many
+SELECT * FROM chirps
+WHERE id IN (
+    SELECT chirp_tags.chirp_id FROM chirp_tags
+    JOIN tags ON tags.id = chirp_tags.tag_id
+    WHERE tags.name = sqlc.arg('tag')
+)
+AND published AND deleted_at IS NULL
+AND (
+    visibility = 'public'
+    OR user_id = sqlc.narg('viewer_id')
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.narg('viewer_id')
+    ))
+)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg('page_size');
+
+-- name: ListTagChirpsDesc :many
+SELECT * FROM chirps
+WHERE id IN (
+    SELECT chirp_tags.chirp_id FROM chirp_tags
+    JOIN tags ON tags.id = chirp_tags.tag_id
+    WHERE tags.name = sqlc.arg('tag')
+)
+AND published AND deleted_at IS NULL
+AND (
+    visibility = 'public'
+    OR user_id = sqlc.narg('viewer_id')
+    OR (visibility = 'followers' AND user_id IN (
+        SELECT followed_id FROM follows WHERE follower_id = sqlc.narg('viewer_id')
+    ))
+)
+AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('page_size');
+
+-- name: GetTrendingTags :many
+SELECT tags.name, COUNT(*) AS chirp_count FROM chirp_tags
+JOIN tags ON tags.id = chirp_tags.tag_id
+JOIN chirps ON chirps.id = chirp_tags.chirp_id
+WHERE chirp_tags.created_at > sqlc.arg('since') AND chirps.published AND chirps.deleted_at IS NULL
+AND chirps.visibility = 'public'
+GROUP BY tags.name
+ORDER BY chirp_count DESC, tags.name ASC
+LIMIT sqlc.arg('max_tags');
+
+-- name: SyncChirpTagTimes :exec
+UPDATE chirp_tags
+SET created_at = chirps.created_at
+FROM chirps
+WHERE chirps.id = chirp_tags.chirp_id AND chirp_tags.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
@@ -1,11 +1,12 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle)
 VALUES (
     gen_random_uuid(),
     NOW(),
     NOW(),
     $1,
-    $2
+    $2,
+    $3
 )
 RETURNING *;
 
@@ -19,21 +20,40 @@ WHERE email = $1;
 -- name: UpdatePasswordAndEmail :one
 UPDATE users
 SET 
-    email = $1,
-    hashed_password = $2,
+    email = sqlc.arg('email'),
+    hashed_password = sqlc.arg('hashed_password'),
+    handle = COALESCE(sqlc.narg('handle'), handle),
     updated_at = NOW()
 WHERE
-    id = $3
+    id = sqlc.arg('id')
 RETURNING *;
 
 -- name: GetUserById :one
 SELECT * FROM users
 WHERE id = $1;
 
+-- name: GetUserByIdForUpdate :one
+SELECT * FROM users
+WHERE id = $1
+FOR UPDATE;
+
 -- name: UpgradeUser :one
 UPDATE users
 SET
     is_chirpy_red = true
 WHERE
     id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: GetUsersByHandles :many
+SELECT id, handle FROM users
+WHERE handle = ANY(sqlc.arg('handles')::text[]);
+
+-- name: UpdateUserPreferences :one
+UPDATE users
+SET
+    expand_content_warnings = $1,
+    updated_at = NOW()
+WHERE
+    id = $2
+RETURNING *;
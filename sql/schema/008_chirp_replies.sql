@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN parent_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+ADD COLUMN root_id UUID REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX chirps_parent_id_idx ON chirps (parent_id);
+CREATE INDEX chirps_root_id_idx ON chirps (root_id, created_at);
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN root_id,
+DROP COLUMN parent_id;
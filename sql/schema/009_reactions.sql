@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE reactions (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    emoji TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id, emoji)
+);
+
+CREATE INDEX reactions_chirp_id_idx ON reactions (chirp_id);
+
+-- +goose Down
+DROP TABLE reactions;
@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE tags (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE chirp_tags (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, tag_id)
+);
+
+CREATE INDEX chirp_tags_tag_id_idx ON chirp_tags (tag_id);
+CREATE INDEX chirp_tags_created_at_idx ON chirp_tags (created_at);
+
+-- +goose Down
+DROP TABLE chirp_tags;
+DROP TABLE tags;
@@ -0,0 +1,20 @@
+-- +goose Up
+ALTER TABLE users
+ADD COLUMN handle TEXT UNIQUE;
+
+CREATE TABLE mentions (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    handle TEXT NOT NULL,
+    start_index INTEGER NOT NULL,
+    end_index INTEGER NOT NULL,
+    PRIMARY KEY (chirp_id, start_index)
+);
+
+CREATE INDEX mentions_user_id_idx ON mentions (user_id);
+
+-- +goose Down
+DROP TABLE mentions;
+
+ALTER TABLE users
+DROP COLUMN handle;
@@ -0,0 +1,7 @@
+-- +goose Up
+-- Search queries have to use the same to_tsvector('english', body)
+-- expression to hit the index
+CREATE INDEX chirps_search_idx ON chirps USING GIN (to_tsvector('english', body));
+
+-- +goose Down
+DROP INDEX chirps_search_idx;
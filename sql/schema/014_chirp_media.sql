@@ -0,0 +1,15 @@
+-- +goose Up
+CREATE TABLE chirp_media (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    storage_key TEXT NOT NULL UNIQUE,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    alt_text TEXT NOT NULL DEFAULT '',
+    UNIQUE (chirp_id, position)
+);
+
+-- +goose Down
+DROP TABLE chirp_media;
@@ -0,0 +1,12 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN publish_at TIMESTAMP,
+ADD COLUMN published BOOL NOT NULL DEFAULT true;
+
+CREATE INDEX chirps_pending_publish_at_idx ON chirps (publish_at)
+WHERE NOT published;
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN published,
+DROP COLUMN publish_at;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX chirps_deleted_at_idx ON chirps (deleted_at)
+WHERE deleted_at IS NOT NULL;
+
+-- A deleted rechirp shouldn't stop the user rechirping the chirp again
+DROP INDEX chirps_user_id_rechirp_of_id_idx;
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_id_idx ON chirps (user_id, rechirp_of_id)
+WHERE rechirp_of_id IS NOT NULL AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_rechirp_of_id_idx;
+DELETE FROM chirps
+WHERE deleted_at IS NOT NULL;
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_id_idx ON chirps (user_id, rechirp_of_id)
+WHERE rechirp_of_id IS NOT NULL;
+
+ALTER TABLE chirps
+DROP COLUMN deleted_at;
@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE drafts (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    parent_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    quote_of_id UUID REFERENCES chirps(id) ON DELETE SET NULL,
+    -- Applied when the draft is published, same choices as a chirp's
+    visibility TEXT NOT NULL DEFAULT 'public'
+        CHECK (visibility IN ('public', 'unlisted', 'followers', 'private'))
+);
+
+CREATE INDEX drafts_user_id_idx ON drafts (user_id, updated_at);
+
+-- +goose Down
+DROP TABLE drafts;
@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE bookmarks (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX bookmarks_user_id_created_at_idx ON bookmarks (user_id, created_at);
+
+-- +goose Down
+DROP TABLE bookmarks;
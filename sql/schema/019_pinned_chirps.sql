@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE pinned_chirps (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+
+-- +goose Down
+DROP TABLE pinned_chirps;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- public: anyone, and shows up in listings
+-- unlisted: anyone with the link, but left out of listings
+-- followers: the author's followers
+-- private: only the author
+ALTER TABLE chirps
+ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+CHECK (visibility IN ('public', 'unlisted', 'followers', 'private'));
+
+-- +goose Down
+ALTER TABLE chirps
+DROP COLUMN visibility;
@@ -0,0 +1,29 @@
+-- +goose Up
+-- content_warning_by_moderator is set when a moderator put the warning
+-- there, so the author can't take it off again
+ALTER TABLE chirps
+ADD COLUMN content_warning TEXT,
+ADD COLUMN content_warning_by_moderator BOOL NOT NULL DEFAULT false;
+
+-- Changing a warning moves updated_at without touching the body, so the
+-- edit history keeps its own record of when the body was last written
+ALTER TABLE chirps
+ADD COLUMN body_updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+UPDATE chirps SET body_updated_at = updated_at;
+
+-- Moderators can put content warnings on anyone's chirps. There is no API to
+-- make someone a moderator, it is set here directly.
+ALTER TABLE users
+ADD COLUMN is_moderator BOOL NOT NULL DEFAULT false,
+ADD COLUMN expand_content_warnings BOOL NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE users
+DROP COLUMN expand_content_warnings,
+DROP COLUMN is_moderator;
+
+ALTER TABLE chirps
+DROP COLUMN body_updated_at,
+DROP COLUMN content_warning_by_moderator,
+DROP COLUMN content_warning;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- A viewer counts once per chirp per time bucket, however often they load it
+CREATE TABLE chirp_impressions (
+    chirp_id UUID NOT NULL REFERENCES chirps(id) ON DELETE CASCADE,
+    viewer_key TEXT NOT NULL,
+    bucket TIMESTAMP NOT NULL,
+    PRIMARY KEY (chirp_id, viewer_key, bucket)
+);
+
+CREATE INDEX chirp_impressions_bucket_idx ON chirp_impressions (bucket);
+
+-- +goose Down
+DROP TABLE chirp_impressions;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- Responses to requests sent with an Idempotency-Key header, replayed when
+-- the request is retried. scope keeps different endpoints and users apart.
+CREATE TABLE idempotency_keys (
+    scope TEXT NOT NULL,
+    key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    -- Empty while the first request is still being handled
+    status_code INTEGER,
+    content_type TEXT,
+    response_body BYTEA,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys (created_at);
+
+-- +goose Down
+DROP TABLE idempotency_keys;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- follower_id follows followed_id
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followed_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followed_id),
+    CHECK (follower_id <> followed_id)
+);
+
+-- The primary key covers who a user follows, this covers their followers
+CREATE INDEX follows_followed_id_idx ON follows (followed_id, created_at);
+
+-- +goose Down
+DROP TABLE follows;
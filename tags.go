@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dis012/ChirpyWebServer/internal"
+	"github.com/dis012/ChirpyWebServer/internal/database"
+)
+
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+	defaultTrendingTags   = 10
+	maxTrendingTags       = 50
+)
+
+type TrendingTag struct {
+	Tag        string `json:"tag"`
+	ChirpCount int64  `json:"chirp_count"`
+}
+
+// Stores the hashtags found in a chirp's body. Run it inside the transaction
+// that writes the chirp so a chirp is never saved without its tags.
+func saveHashtags(ctx context.Context, q *database.Queries, chirp database.Chirp) error {
+	for _, name := range internal.ExtractHashtags(chirp.Body) {
+		tag, err := q.UpsertTag(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		err = q.AddChirpTag(ctx, database.AddChirpTagParams{
+			ChirpID:   chirp.ID,
+			TagID:     tag.ID,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a *apiConfig) getTagChirpsHandler(w http.ResponseWriter, r *http.Request) {
+	tag := internal.NormalizeHashtag(r.PathValue("tag"))
+	if tag == "" {
+		http.Error(w, "Invalid tag", http.StatusBadRequest)
+		return
+	}
+
+	viewer, err := a.optionalUserID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	page, err := parsePageRequest(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cursorCreatedAt, cursorID := page.cursorArgs()
+	listParams := database.ListTagChirpsAscParams{
+		Tag:             tag,
+		ViewerID:        viewer,
+		CursorCreatedAt: cursorCreatedAt,
+		CursorID:        cursorID,
+		PageSize:        page.Limit + 1,
+	}
+
+	var chirps []database.Chirp
+	if page.ascending() {
+		chirps, err = a.dbQueries.ListTagChirpsAsc(r.Context(), listParams)
+	} else {
+		chirps, err = a.dbQueries.ListTagChirpsDesc(r.Context(), database.ListTagChirpsDescParams(listParams))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	chirps, next, prev := buildPage(page, chirps, chirpPosition)
+
+	chirpsSet, err := a.chirpsResponse(r.Context(), chirps, viewer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ChirpPage{
+		Chirps:     chirpsSet,
+		NextCursor: next,
+		PrevCursor: prev,
+	})
+}
+
+// Tags used in the most chirps over the last window (24h by default), so the
+// list follows what people are talking about right now
+func (a *apiConfig) getTrendingTagsHandler(w http.ResponseWriter, r *http.Request) {
+	window := defaultTrendingWindow
+	if param := r.URL.Query().Get("window"); param != "" {
+		d, err := time.ParseDuration(param)
+		if err != nil || d <= 0 || d > maxTrendingWindow {
+			http.Error(w, "window must be a duration between 0 and "+maxTrendingWindow.String(), http.StatusBadRequest)
+			return
+		}
+		window = d
+	}
+
+	limit := defaultTrendingTags
+	if param := r.URL.Query().Get("limit"); param != "" {
+		n, err := strconv.Atoi(param)
+		if err != nil || n < 1 || n > maxTrendingTags {
+			http.Error(w, "limit must be between 1 and "+strconv.Itoa(maxTrendingTags), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	tags, err := a.dbQueries.GetTrendingTags(r.Context(), database.GetTrendingTagsParams{
+		Since:   time.Now().UTC().Add(-window),
+		MaxTags: int32(limit),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	trending := []TrendingTag{}
+	for _, tag := range tags {
+		trending = append(trending, TrendingTag{
+			Tag:        tag.Name,
+			ChirpCount: tag.ChirpCount,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(trending)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// A chirp together with the replies made to it
+type ChirpThread struct {
+	Chirp
+	Replies []*ChirpThread `json:"replies"`
+}
+
+func (a *apiConfig) getChirpThreadHandler(w http.ResponseWriter, r *http.Request) {
+	chirpID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid UUID", http.StatusBadRequest)
+		return
+	}
+
+	viewer, err := a.optionalUserID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	chirp, err := a.dbQueries.GetChirpById(r.Context(), chirpID)
+	if err == nil {
+		err = a.checkCanView(r.Context(), chirp, viewer)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Always return the whole conversation, starting from its first chirp.
+	// If that one was deleted, start from the requested chirp instead.
+	rootID := chirp.ID
+	if chirp.RootID.Valid {
+		rootID = chirp.RootID.UUID
+	}
+	top := chirp
+	if rootID != chirp.ID {
+		top, err = a.dbQueries.GetChirpById(r.Context(), rootID)
+		if err == nil {
+			err = a.checkCanView(r.Context(), top, viewer)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			top = chirp
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	replies, err := a.dbQueries.GetThreadChirps(r.Context(), uuid.NullUUID{UUID: rootID, Valid: true})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	visible, err := a.visibleChirps(r.Context(), replies, viewer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	visibleIDs := make(map[uuid.UUID]bool, len(visible))
+	for _, reply := range visible {
+		visibleIDs[reply.ID] = true
+	}
+
+	parents := make(map[uuid.UUID]uuid.NullUUID, len(replies))
+	for _, reply := range replies {
+		parents[reply.ID] = reply.ParentID
+	}
+	// The requested chirp and the replies leading up to it
+	onPath := map[uuid.UUID]bool{chirp.ID: true}
+	for parent := parents[chirp.ID]; parent.Valid && !onPath[parent.UUID]; parent = parents[parent.UUID] {
+		onPath[parent.UUID] = true
+	}
+
+	// Replies the viewer may not see are left out along with their own
+	// replies, except for the path to the requested chirp, which the viewer
+	// is allowed to see. Replies come oldest first, so a reply's parent has
+	// always been looked at before it.
+	hidden := make(map[uuid.UUID]bool)
+	rows := []database.Chirp{top}
+	for _, reply := range replies {
+		if !visibleIDs[reply.ID] || !onPath[reply.ID] && reply.ParentID.Valid && hidden[reply.ParentID.UUID] {
+			hidden[reply.ID] = true
+			continue
+		}
+		if reply.ID != top.ID {
+			rows = append(rows, reply)
+		}
+	}
+
+	chirps, err := a.chirpsResponse(r.Context(), rows, viewer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	threads := make(map[uuid.UUID]*ChirpThread, len(chirps))
+	for _, c := range chirps {
+		threads[c.ID] = &ChirpThread{Chirp: c, Replies: []*ChirpThread{}}
+	}
+
+	// Replies come oldest first, so children end up in the order they were made.
+	// A reply whose parent was left out hangs off its nearest ancestor that
+	// is shown. If that was deleted too it hangs off the root instead, unless
+	// we are only showing part of the conversation.
+	for _, c := range chirps[1:] {
+		parentID := c.ParentID
+		for parentID.Valid && threads[parentID.UUID] == nil {
+			parentID = parents[parentID.UUID]
+		}
+		parent := threads[parentID.UUID]
+		if !parentID.Valid {
+			if top.ID != rootID {
+				continue
+			}
+			parent = threads[top.ID]
+		}
+		parent.Replies = append(parent.Replies, threads[c.ID])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(threads[top.ID])
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/dis012/ChirpyWebServer/internal/database"
+	"github.com/google/uuid"
+)
+
+// Who gets to see a chirp
+const (
+	visibilityPublic    = "public"
+	visibilityUnlisted  = "unlisted"
+	visibilityFollowers = "followers"
+	visibilityPrivate   = "private"
+)
+
+// Fills in the default for a chirp sent without a visibility and rejects
+// anything we don't know
+func validateVisibility(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return visibilityPublic, nil
+	case visibilityPublic, visibilityUnlisted, visibilityFollowers, visibilityPrivate:
+		return visibility, nil
+	}
+	return "", errors.New("visibility must be public, unlisted, followers or private")
+}
+
+// Reports whether viewer may see chirp. Authors always see their own chirps.
+// Scheduled chirps stay private to their author until they are published.
+// following tells whether viewer follows the author, which opens up
+// followers-only chirps. Listings go further and leave out unlisted chirps,
+// which the list queries take care of.
+func canView(chirp database.Chirp, viewer uuid.NullUUID, following bool) bool {
+	if viewer.Valid && viewer.UUID == chirp.UserID {
+		return true
+	}
+	if !chirp.Published {
+		return false
+	}
+
+	switch chirp.Visibility {
+	case visibilityPublic, visibilityUnlisted:
+		return true
+	case visibilityFollowers:
+		return following
+	}
+	return false
+}
+
+// Returns sql.ErrNoRows when viewer may not see chirp, so callers can treat
+// it like a chirp that doesn't exist
+func (a *apiConfig) checkCanView(ctx context.Context, chirp database.Chirp, viewer uuid.NullUUID) error {
+	visible, err := a.visibleChirps(ctx, []database.Chirp{chirp}, viewer)
+	if err != nil {
+		return err
+	}
+	if len(visible) == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Drops the chirps viewer may not see
+func (a *apiConfig) visibleChirps(ctx context.Context, chirps []database.Chirp, viewer uuid.NullUUID) ([]database.Chirp, error) {
+	following, err := a.followedAuthors(ctx, chirps, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []database.Chirp{}
+	for _, chirp := range chirps {
+		if canView(chirp, viewer, following[chirp.UserID]) {
+			visible = append(visible, chirp)
+		}
+	}
+	return visible, nil
+}
+
+// Which authors of the followers-only chirps viewer follows. The database is
+// only asked when there are such chirps by someone else.
+func (a *apiConfig) followedAuthors(ctx context.Context, chirps []database.Chirp, viewer uuid.NullUUID) (map[uuid.UUID]bool, error) {
+	following := make(map[uuid.UUID]bool)
+	if !viewer.Valid {
+		return following, nil
+	}
+
+	var authorIDs []uuid.UUID
+	for _, chirp := range chirps {
+		if chirp.Visibility == visibilityFollowers && chirp.UserID != viewer.UUID {
+			authorIDs = append(authorIDs, chirp.UserID)
+		}
+	}
+	if len(authorIDs) == 0 {
+		return following, nil
+	}
+
+	followedIDs, err := a.dbQueries.GetFollowedUserIds(ctx, database.GetFollowedUserIdsParams{
+		FollowerID: viewer.UUID,
+		UserIds:    authorIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range followedIDs {
+		following[id] = true
+	}
+	return following, nil
+}